@@ -7,6 +7,7 @@ import (
 	"os/signal"
 	"simple-log-store/internal"
 	"simple-log-store/internal/utils"
+	"syscall"
 )
 
 func main() {
@@ -20,7 +21,7 @@ func main() {
 		return
 	}
 
-	ctx, cancelFunc := signal.NotifyContext(context.Background(), os.Interrupt)
+	ctx, cancelFunc := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer cancelFunc()
 
 	err = app.Start(ctx)
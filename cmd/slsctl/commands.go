@@ -0,0 +1,412 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/oklog/ulid/v2"
+	"os"
+	"simple-log-store/internal"
+	"simple-log-store/internal/logs"
+	"simple-log-store/internal/redis"
+	"slices"
+	"text/tabwriter"
+	"time"
+)
+
+func newTabWriter() *tabwriter.Writer {
+	return tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+}
+
+func parseBundleIds(args []string) ([]logs.LogBundleId, error) {
+	if len(args) < 1 {
+		return nil, fmt.Errorf("expected at least one log bundle ID")
+	}
+
+	res := make([]logs.LogBundleId, len(args))
+	for i, arg := range args {
+		logBundleId, err := logs.ParseId(arg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid log bundle ID `%s`: %w", arg, err)
+		}
+
+		res[i] = logBundleId
+	}
+
+	return res, nil
+}
+
+func formatExpiry(ttl time.Duration, expires bool) string {
+	if !expires {
+		return "pinned"
+	}
+
+	return time.Now().Add(ttl).UTC().Format(time.RFC3339)
+}
+
+func formatBytes(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
+
+func listBundles(ctx context.Context, app *internal.App, _ []string) error {
+	logBundleIds, err := app.RedisService.ListLogBundles(ctx)
+	if err != nil {
+		return err
+	}
+
+	slices.SortFunc(logBundleIds, func(a, b logs.LogBundleId) int {
+		return a.Compare(b)
+	})
+
+	w := newTabWriter()
+	_, _ = fmt.Fprintln(w, "ID\tCREATED\tEXPIRES\tFILES")
+
+	for _, logBundleId := range logBundleIds {
+		logFileIds, err := app.RedisService.GetLogBundle(ctx, logBundleId)
+		if err != nil {
+			if errors.Is(err, redis.ErrNotFound) {
+				continue
+			}
+
+			return err
+		}
+
+		ttl, expires, err := app.RedisService.GetLogBundleExpiry(ctx, logBundleId)
+		if err != nil {
+			if errors.Is(err, redis.ErrNotFound) {
+				continue
+			}
+
+			return err
+		}
+
+		createdAt := ulid.Time(logBundleId.Time()).UTC().Format(time.RFC3339)
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%d\n", logBundleId.String(), createdAt, formatExpiry(ttl, expires), len(logFileIds))
+	}
+
+	return w.Flush()
+}
+
+func showBundle(ctx context.Context, app *internal.App, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("expected exactly one log bundle ID")
+	}
+
+	logBundleIds, err := parseBundleIds(args)
+	if err != nil {
+		return err
+	}
+
+	logBundleId := logBundleIds[0]
+	logBundle, err := app.RedisService.GetLogBundleRecord(ctx, logBundleId)
+	if err != nil {
+		return err
+	}
+
+	logFileIds := logBundle.LogFileIds
+	ttl, expires, err := app.RedisService.GetLogBundleExpiry(ctx, logBundleId)
+	if err != nil {
+		return err
+	}
+
+	w := newTabWriter()
+	_, _ = fmt.Fprintf(w, "ID:\t%s\n", logBundleId.String())
+	_, _ = fmt.Fprintf(w, "Created:\t%s\n", ulid.Time(logBundleId.Time()).UTC().Format(time.RFC3339))
+	_, _ = fmt.Fprintf(w, "Expires:\t%s\n", formatExpiry(ttl, expires))
+	_, _ = fmt.Fprintf(w, "Files:\t%d\n", len(logFileIds))
+	if logBundle.Title != "" {
+		_, _ = fmt.Fprintf(w, "Title:\t%s\n", logBundle.Title)
+	}
+
+	tagKeys := make([]string, 0, len(logBundle.Tags))
+	for key := range logBundle.Tags {
+		tagKeys = append(tagKeys, key)
+	}
+
+	slices.Sort(tagKeys)
+	for _, key := range tagKeys {
+		_, _ = fmt.Fprintf(w, "Tag:\t%s:%s\n", key, logBundle.Tags[key])
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	_, _ = fmt.Println()
+
+	w = newTabWriter()
+	_, _ = fmt.Fprintln(w, "FILE\tSIZE\tMODIFIED")
+
+	for _, logFileId := range logFileIds {
+		logFileInfo, err := app.StorageService.StatLogFile(logFileId)
+		if err != nil {
+			if os.IsNotExist(err) {
+				_, _ = fmt.Fprintf(w, "%s\tmissing\t\n", logFileId.String())
+				continue
+			}
+
+			return err
+		}
+
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\n", logFileId.String(), formatBytes(logFileInfo.Size), logFileInfo.ModificationTime.UTC().Format(time.RFC3339))
+	}
+
+	return w.Flush()
+}
+
+func deleteBundles(ctx context.Context, app *internal.App, args []string) error {
+	logBundleIds, err := parseBundleIds(args)
+	if err != nil {
+		return err
+	}
+
+	for _, logBundleId := range logBundleIds {
+		logFileIds, err := app.RedisService.GetLogBundle(ctx, logBundleId)
+		if err != nil {
+			return err
+		}
+
+		if err := app.RedisService.DeleteLogBundle(ctx, logBundleId); err != nil {
+			return err
+		}
+
+		for _, logFileId := range logFileIds {
+			if err := app.StorageService.DeleteLogFile(logFileId); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("failed to delete log file `%s`: %w", logFileId.String(), err)
+			}
+		}
+
+		_, _ = fmt.Printf("deleted %s (%d files)\n", logBundleId.String(), len(logFileIds))
+	}
+
+	return nil
+}
+
+func pinBundles(ctx context.Context, app *internal.App, args []string) error {
+	logBundleIds, err := parseBundleIds(args)
+	if err != nil {
+		return err
+	}
+
+	for _, logBundleId := range logBundleIds {
+		if err := app.RedisService.PinLogBundle(ctx, logBundleId); err != nil {
+			return err
+		}
+
+		_, _ = fmt.Printf("pinned %s\n", logBundleId.String())
+	}
+
+	return nil
+}
+
+func unpinBundles(ctx context.Context, app *internal.App, args []string) error {
+	logBundleIds, err := parseBundleIds(args)
+	if err != nil {
+		return err
+	}
+
+	for _, logBundleId := range logBundleIds {
+		if err := app.RedisService.UnpinLogBundle(ctx, logBundleId); err != nil {
+			return err
+		}
+
+		_, _ = fmt.Printf("unpinned %s\n", logBundleId.String())
+	}
+
+	return nil
+}
+
+func listDeliveries(ctx context.Context, app *internal.App, args []string) error {
+	flagSet := flag.NewFlagSet("deliveries", flag.ContinueOnError)
+	status := flagSet.String("status", "", "only list deliveries with the status, `pending`, `succeeded` or `failed`")
+	if err := flagSet.Parse(args); err != nil {
+		return err
+	}
+
+	query := redis.WebhookDeliveryQuery{
+		Status: *status,
+		Limit:  100,
+	}
+
+	if flagSet.NArg() > 0 {
+		logBundleIds, err := parseBundleIds(flagSet.Args()[:1])
+		if err != nil {
+			return err
+		}
+
+		query.LogBundleId = logBundleIds[0]
+	}
+
+	w := newTabWriter()
+	_, _ = fmt.Fprintln(w, "DELIVERY\tBUNDLE\tSTATUS\tATTEMPTS\tUPDATED\tURL\tERROR")
+
+	var zeroId ulid.ULID
+	for {
+		page, err := app.RedisService.QueryWebhookDeliveries(ctx, query)
+		if err != nil {
+			return err
+		}
+
+		for _, delivery := range page.Deliveries {
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%s\t%s\n", delivery.DeliveryId.String(), delivery.LogBundleId.String(), delivery.Status, delivery.Attempts, delivery.UpdatedAt.Format(time.RFC3339), delivery.Url, delivery.Error)
+		}
+
+		if page.NextCursor == zeroId {
+			break
+		}
+
+		query.Cursor = page.NextCursor
+	}
+
+	return w.Flush()
+}
+
+func reindexBundles(ctx context.Context, app *internal.App, args []string) error {
+	var logBundleIds []logs.LogBundleId
+	var err error
+
+	if len(args) == 0 {
+		logBundleIds, err = app.RedisService.ListLogBundles(ctx)
+	} else {
+		logBundleIds, err = parseBundleIds(args)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	for _, logBundleId := range logBundleIds {
+		logFileIds, err := app.RedisService.GetLogBundle(ctx, logBundleId)
+		if err != nil {
+			return err
+		}
+
+		// log bundles created before the secondary indexes existed aren't listed otherwise
+		if err := app.RedisService.IndexLogBundle(ctx, logBundleId); err != nil {
+			return err
+		}
+
+		app.IndexService.IndexLogBundle(ctx, logBundleId, logFileIds)
+		_, _ = fmt.Printf("indexed %s (%d files)\n", logBundleId.String(), len(logFileIds))
+	}
+
+	return nil
+}
+
+func runRetention(ctx context.Context, app *internal.App, _ []string) error {
+	removedCount, err := app.RemoveOldLogFiles(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, _ = fmt.Printf("removed %d log files older than %s\n", removedCount, app.Config.LogRetentionDuration)
+	return nil
+}
+
+func reportUsage(_ context.Context, app *internal.App, _ []string) error {
+	w := newTabWriter()
+	_, _ = fmt.Fprintln(w, "DIRECTORY\tPATH\tFILES\tSIZE\tFREE")
+
+	stagingUsage, err := app.StorageService.GetStagingUsage()
+	if err != nil {
+		return err
+	}
+
+	_, _ = fmt.Fprintf(w, "staging\t%s\t%d\t%s\t%s\n", stagingUsage.Path, stagingUsage.FileCount, formatBytes(stagingUsage.Bytes), formatFreeBytes(stagingUsage.FreeBytes, stagingUsage.HasFreeBytes))
+
+	storageUsage, err := app.StorageService.GetStorageUsage()
+	if err != nil {
+		return err
+	}
+
+	_, _ = fmt.Fprintf(w, "storage\t%s\t%d\t%s\t%s\n", storageUsage.Path, storageUsage.FileCount, formatBytes(storageUsage.Bytes), formatFreeBytes(storageUsage.FreeBytes, storageUsage.HasFreeBytes))
+
+	return w.Flush()
+}
+
+func formatFreeBytes(freeBytes uint64, hasFreeBytes bool) string {
+	if !hasFreeBytes {
+		return "unknown"
+	}
+
+	return formatBytes(int64(freeBytes))
+}
+
+func verify(ctx context.Context, app *internal.App, _ []string) error {
+	problems := 0
+	reportProblem := func(format string, a ...any) {
+		problems += 1
+		_, _ = fmt.Printf(format+"\n", a...)
+	}
+
+	logBundleIds, err := app.RedisService.ListLogBundles(ctx)
+	if err != nil {
+		return err
+	}
+
+	referencedLogFileIds := make(map[logs.LogFileId]logs.LogBundleId)
+	for _, logBundleId := range logBundleIds {
+		logFileIds, err := app.RedisService.GetLogBundle(ctx, logBundleId)
+		if err != nil {
+			if errors.Is(err, redis.ErrNotFound) {
+				continue
+			}
+
+			return err
+		}
+
+		if len(logFileIds) == 0 {
+			reportProblem("log bundle %s has no log files", logBundleId.String())
+		}
+
+		for _, logFileId := range logFileIds {
+			if otherLogBundleId, exists := referencedLogFileIds[logFileId]; exists {
+				reportProblem("log file %s is referenced by log bundles %s and %s", logFileId.String(), otherLogBundleId.String(), logBundleId.String())
+			}
+
+			referencedLogFileIds[logFileId] = logBundleId
+
+			if _, err := app.StorageService.StatLogFile(logFileId); err != nil {
+				if os.IsNotExist(err) {
+					reportProblem("log bundle %s references missing log file %s", logBundleId.String(), logFileId.String())
+					continue
+				}
+
+				return err
+			}
+		}
+	}
+
+	logFileInfos, unknownFiles, err := app.StorageService.ListLogFiles()
+	if err != nil {
+		return err
+	}
+
+	for _, logFileInfo := range logFileInfos {
+		if _, isReferenced := referencedLogFileIds[logFileInfo.Id]; !isReferenced {
+			reportProblem("log file %s (%s) doesn't belong to a log bundle", logFileInfo.Id.String(), formatBytes(logFileInfo.Size))
+		}
+	}
+
+	for _, unknownFile := range unknownFiles {
+		reportProblem("unknown file %s in storage directory", unknownFile)
+	}
+
+	_, _ = fmt.Printf("checked %d log bundles and %d log files, found %d problem(s)\n", len(logBundleIds), len(logFileInfos), problems)
+	if problems != 0 {
+		return errProblemsFound
+	}
+
+	return nil
+}
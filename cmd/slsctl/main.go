@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"simple-log-store/internal"
+	"syscall"
+)
+
+type command struct {
+	name        string
+	usage       string
+	description string
+	run         func(ctx context.Context, app *internal.App, args []string) error
+}
+
+var commands = []command{
+	{name: "list", usage: "list", description: "list all log bundles", run: listBundles},
+	{name: "show", usage: "show <logBundleId>", description: "show details of a log bundle", run: showBundle},
+	{name: "delete", usage: "delete <logBundleId>...", description: "delete log bundles and their log files", run: deleteBundles},
+	{name: "pin", usage: "pin <logBundleId>...", description: "exempt log bundles from the retention", run: pinBundles},
+	{name: "unpin", usage: "unpin <logBundleId>...", description: "subject pinned log bundles to the retention again", run: unpinBundles},
+	{name: "deliveries", usage: "deliveries [-status s] [logBundleId]", description: "list webhook deliveries, of a log bundle if given", run: listDeliveries},
+	{name: "reindex", usage: "reindex [logBundleId...]", description: "rebuild the indexes of log bundles, all by default", run: reindexBundles},
+	{name: "retention", usage: "retention", description: "remove old log files immediately", run: runRetention},
+	{name: "usage", usage: "usage", description: "report storage usage", run: reportUsage},
+	{name: "verify", usage: "verify", description: "verify that log bundles and log files are consistent", run: verify},
+}
+
+// errProblemsFound is returned by commands that completed but found problems.
+var errProblemsFound = errors.New("problems found")
+
+func printUsage(w io.Writer) {
+	_, _ = fmt.Fprintln(w, "Usage: slsctl <command> [arguments]")
+	_, _ = fmt.Fprintln(w)
+	_, _ = fmt.Fprintln(w, "slsctl uses the same environment variables as the server.")
+	_, _ = fmt.Fprintln(w)
+	_, _ = fmt.Fprintln(w, "Commands:")
+	for _, cmd := range commands {
+		_, _ = fmt.Fprintf(w, "  %-38s %s\n", cmd.usage, cmd.description)
+	}
+}
+
+func main() {
+	os.Exit(run())
+}
+
+func run() int {
+	verbose := flag.Bool("v", false, "enable verbose logging")
+	flag.Usage = func() {
+		printUsage(flag.CommandLine.Output())
+		_, _ = fmt.Fprintln(flag.CommandLine.Output())
+		_, _ = fmt.Fprintln(flag.CommandLine.Output(), "Flags:")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() < 1 {
+		flag.Usage()
+		return 2
+	}
+
+	var selected *command
+	for i := range commands {
+		if commands[i].name == flag.Arg(0) {
+			selected = &commands[i]
+			break
+		}
+	}
+
+	if selected == nil {
+		_, _ = fmt.Fprintf(os.Stderr, "unknown command `%s`\n\n", flag.Arg(0))
+		flag.Usage()
+		return 2
+	}
+
+	logLevel := slog.LevelWarn
+	if *verbose {
+		logLevel = slog.LevelDebug
+	}
+
+	logWriter := os.Stderr
+	logger := slog.New(slog.NewTextHandler(logWriter, &slog.HandlerOptions{Level: logLevel})).With(slog.String("service", "slsctl"))
+	slog.SetDefault(logger)
+
+	app, err := internal.New(logger, io.Discard)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "failed to create app: %v\n", err)
+		return 1
+	}
+
+	defer app.RedisService.Close()
+
+	ctx, cancelFunc := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancelFunc()
+
+	if err := app.RedisService.Ping(ctx); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 1
+	}
+
+	if err := selected.run(ctx, app, flag.Args()[1:]); err != nil {
+		if !errors.Is(err, errProblemsFound) {
+			_, _ = fmt.Fprintf(os.Stderr, "%s: %v\n", selected.name, err)
+		}
+
+		return 1
+	}
+
+	return 0
+}
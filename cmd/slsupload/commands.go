@@ -0,0 +1,267 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/oklog/ulid/v2"
+	"os"
+	"path/filepath"
+	"simple-log-store/pkg/client"
+	"strings"
+)
+
+func expandGlobs(patterns []string) ([]string, error) {
+	var res []string
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern `%s`: %w", pattern, err)
+		}
+
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no files match `%s`", pattern)
+		}
+
+		res = append(res, matches...)
+	}
+
+	return res, nil
+}
+
+func parseBundleId(flagSet *flag.FlagSet) (ulid.ULID, error) {
+	if flagSet.NArg() < 1 {
+		flagSet.Usage()
+		return ulid.ULID{}, errors.New("expected a log bundle ID")
+	}
+
+	logBundleId, err := ulid.ParseStrict(flagSet.Arg(0))
+	if err != nil {
+		return logBundleId, fmt.Errorf("invalid log bundle ID `%s`: %w", flagSet.Arg(0), err)
+	}
+
+	return logBundleId, nil
+}
+
+func upload(ctx context.Context, c *client.Client, args []string) error {
+	flagSet := newFlagSet("upload")
+	useGzip := flagSet.Bool("gzip", false, "compress files before uploading")
+	title := flagSet.String("title", "", "title of the log bundle")
+	description := flagSet.String("description", "", "description of the log bundle")
+	tags := make(map[string]string)
+	flagSet.Func("tag", "tag of the log bundle as `key:value`, can be repeated", func(value string) error {
+		key, tagValue, _ := strings.Cut(value, ":")
+		if key == "" {
+			return errors.New("expected `key:value`")
+		}
+
+		tags[key] = tagValue
+		return nil
+	})
+
+	if err := flagSet.Parse(args); err != nil {
+		return err
+	}
+
+	if flagSet.NArg() < 1 {
+		flagSet.Usage()
+		return errors.New("expected at least one file")
+	}
+
+	paths, err := expandGlobs(flagSet.Args())
+	if err != nil {
+		return err
+	}
+
+	files := make([]client.File, 0, len(paths))
+	for _, path := range paths {
+		file, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("failed to open file: %w", err)
+		}
+
+		defer func(file *os.File) {
+			_ = file.Close()
+		}(file)
+
+		files = append(files, client.File{
+			Name:   filepath.Base(path),
+			Reader: file,
+		})
+	}
+
+	res, err := c.Upload(ctx, files, client.UploadOptions{
+		Gzip:        *useGzip,
+		Title:       *title,
+		Description: *description,
+		Tags:        tags,
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("bundle: %s\n", res.BundleId.String())
+	fmt.Printf("bundle url: %s\n", c.BundleUrl(res.BundleId))
+	fmt.Printf("view url: %s\n", c.ViewUrl(res.BundleId))
+	return nil
+}
+
+func download(ctx context.Context, c *client.Client, args []string) error {
+	flagSet := newFlagSet("download")
+	outputDirectory := flagSet.String("o", ".", "output directory")
+	if err := flagSet.Parse(args); err != nil {
+		return err
+	}
+
+	logBundleId, err := parseBundleId(flagSet)
+	if err != nil {
+		return err
+	}
+
+	logFileIds, err := c.GetBundle(ctx, logBundleId)
+	if err != nil {
+		return err
+	}
+
+	outputPath := filepath.Join(*outputDirectory, logBundleId.String())
+	if err := os.MkdirAll(outputPath, 0o755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	for _, logFileId := range logFileIds {
+		filePath := filepath.Join(outputPath, logFileId.String())
+		if err := downloadToFile(filePath, func(file *os.File) error {
+			return c.DownloadFile(ctx, logFileId, file)
+		}); err != nil {
+			return err
+		}
+
+		fmt.Println(filePath)
+	}
+
+	return nil
+}
+
+func archive(ctx context.Context, c *client.Client, args []string) error {
+	flagSet := newFlagSet("archive")
+	outputFile := flagSet.String("o", "", "output file, defaults to `<logBundleId>.zip`")
+	if err := flagSet.Parse(args); err != nil {
+		return err
+	}
+
+	logBundleId, err := parseBundleId(flagSet)
+	if err != nil {
+		return err
+	}
+
+	filePath := *outputFile
+	if filePath == "" {
+		filePath = logBundleId.String() + ".zip"
+	}
+
+	if err := downloadToFile(filePath, func(file *os.File) error {
+		return c.DownloadArchive(ctx, logBundleId, file)
+	}); err != nil {
+		return err
+	}
+
+	fmt.Println(filePath)
+	return nil
+}
+
+func downloadToFile(filePath string, downloadFunc func(file *os.File) error) error {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+
+	err = downloadFunc(file)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		_ = os.Remove(filePath)
+		return err
+	}
+
+	return nil
+}
+
+func lines(ctx context.Context, c *client.Client, args []string) error {
+	flagSet := newFlagSet("lines")
+	tail := flagSet.Int("tail", 0, "number of lines at the end of the log file")
+	lineRange := flagSet.String("range", "", "inclusive range of line numbers like `1000-2000`")
+	if err := flagSet.Parse(args); err != nil {
+		return err
+	}
+
+	if flagSet.NArg() < 1 {
+		flagSet.Usage()
+		return errors.New("expected a log file ID")
+	}
+
+	logFileId, err := ulid.ParseStrict(flagSet.Arg(0))
+	if err != nil {
+		return fmt.Errorf("invalid log file ID `%s`: %w", flagSet.Arg(0), err)
+	}
+
+	var options client.LineOptions
+	switch {
+	case *tail > 0 && *lineRange != "":
+		return errors.New("-tail and -range can't be combined")
+	case *tail > 0:
+		options.Tail = *tail
+	case *lineRange != "":
+		if _, err := fmt.Sscanf(*lineRange, "%d-%d", &options.First, &options.Last); err != nil {
+			return fmt.Errorf("invalid range `%s`, expected `first-last`", *lineRange)
+		}
+	default:
+		flagSet.Usage()
+		return errors.New("expected -tail or -range")
+	}
+
+	return c.DownloadLines(ctx, logFileId, options, os.Stdout)
+}
+
+func search(ctx context.Context, c *client.Client, args []string) error {
+	flagSet := newFlagSet("search")
+	useRegex := flagSet.Bool("regex", false, "interpret the query as a regular expression")
+	caseInsensitive := flagSet.Bool("i", false, "ignore case")
+	limit := flagSet.Int("limit", 0, "maximum number of matches, defaults to the server limit")
+	if err := flagSet.Parse(args); err != nil {
+		return err
+	}
+
+	logBundleId, err := parseBundleId(flagSet)
+	if err != nil {
+		return err
+	}
+
+	if flagSet.NArg() != 2 {
+		flagSet.Usage()
+		return errors.New("expected a query")
+	}
+
+	res, err := c.Search(ctx, logBundleId, client.SearchOptions{
+		Query:           flagSet.Arg(1),
+		Regex:           *useRegex,
+		CaseInsensitive: *caseInsensitive,
+		Limit:           *limit,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	for _, match := range res.Matches {
+		fmt.Printf("%s:%d:%s\n", match.FileId.String(), match.Line, match.Text)
+	}
+
+	if res.Truncated {
+		_, _ = fmt.Fprintln(os.Stderr, "results were truncated, use -limit to get more matches")
+	}
+
+	return nil
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"simple-log-store/pkg/client"
+	"syscall"
+)
+
+type command struct {
+	name        string
+	usage       string
+	description string
+	run         func(ctx context.Context, c *client.Client, args []string) error
+}
+
+var commands = []command{
+	{name: "upload", usage: "upload [-gzip] [-title t] [-tag k:v]... <file|glob>...", description: "upload files as a new log bundle", run: upload},
+	{name: "download", usage: "download [-o dir] <logBundleId>", description: "download all files of a log bundle", run: download},
+	{name: "archive", usage: "archive [-o file] <logBundleId>", description: "download a log bundle as a zip archive", run: archive},
+	{name: "lines", usage: "lines [-tail n | -range first-last] <logFileId>", description: "print lines of a log file", run: lines},
+	{name: "search", usage: "search [-regex] [-i] [-limit n] <logBundleId> <query>", description: "search all files of a log bundle", run: search},
+}
+
+const defaultServer = "http://localhost:3000"
+
+func printUsage(w io.Writer) {
+	_, _ = fmt.Fprintln(w, "Usage: slsupload [-server url] [-token token] <command> [arguments]")
+	_, _ = fmt.Fprintln(w)
+	_, _ = fmt.Fprintln(w, "Commands:")
+	for _, cmd := range commands {
+		_, _ = fmt.Fprintf(w, "  %-55s %s\n", cmd.usage, cmd.description)
+	}
+}
+
+func main() {
+	os.Exit(run())
+}
+
+func run() int {
+	server := os.Getenv("SLS_SERVER")
+	if server == "" {
+		server = defaultServer
+	}
+
+	flag.StringVar(&server, "server", server, "URL of the server, defaults to $SLS_SERVER")
+	token := flag.String("token", os.Getenv("SLS_TOKEN"), "upload key, defaults to $SLS_TOKEN")
+	flag.Usage = func() {
+		printUsage(flag.CommandLine.Output())
+		_, _ = fmt.Fprintln(flag.CommandLine.Output())
+		_, _ = fmt.Fprintln(flag.CommandLine.Output(), "Flags:")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() < 1 {
+		flag.Usage()
+		return 2
+	}
+
+	var selected *command
+	for i := range commands {
+		if commands[i].name == flag.Arg(0) {
+			selected = &commands[i]
+			break
+		}
+	}
+
+	if selected == nil {
+		_, _ = fmt.Fprintf(os.Stderr, "unknown command `%s`\n\n", flag.Arg(0))
+		flag.Usage()
+		return 2
+	}
+
+	c, err := client.New(server, client.WithToken(*token))
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 1
+	}
+
+	ctx, cancelFunc := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancelFunc()
+
+	if err := selected.run(ctx, c, flag.Args()[1:]); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "%s: %v\n", selected.name, err)
+		return 1
+	}
+
+	return 0
+}
+
+func newFlagSet(name string) *flag.FlagSet {
+	return flag.NewFlagSet(name, flag.ContinueOnError)
+}
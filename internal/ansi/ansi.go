@@ -0,0 +1,83 @@
+package ansi
+
+import (
+	"io"
+	"strings"
+)
+
+// Segment is a run of text with the same style.
+type Segment struct {
+	Text  string
+	Style Style
+}
+
+// Parse splits the text into styled segments and drops all escape sequences. The style
+// starts out as the default style, so every line can be parsed on its own.
+func Parse(text string) []Segment {
+	if !strings.ContainsRune(text, escape) {
+		return []Segment{{Text: text}}
+	}
+
+	var segments []Segment
+	var style Style
+	var p parser
+
+	p.parse([]byte(text), func(data []byte) {
+		last := len(segments) - 1
+		if last >= 0 && segments[last].Style == style {
+			segments[last].Text += string(data)
+			return
+		}
+
+		segments = append(segments, Segment{Text: string(data), Style: style})
+	}, style.apply)
+
+	return segments
+}
+
+// Strip removes all escape sequences from the text.
+func Strip(text string) string {
+	if !strings.ContainsRune(text, escape) {
+		return text
+	}
+
+	var builder strings.Builder
+	builder.Grow(len(text))
+
+	var p parser
+	p.parse([]byte(text), func(data []byte) {
+		builder.Write(data)
+	}, func([]byte) {})
+
+	return builder.String()
+}
+
+// StripWriter removes all escape sequences from the data written to the underlying writer.
+// Escape sequences can be split across multiple writes.
+type StripWriter struct {
+	writer io.Writer
+	parser parser
+	err    error
+}
+
+func NewStripWriter(writer io.Writer) *StripWriter {
+	return &StripWriter{writer: writer}
+}
+
+func (w *StripWriter) Write(data []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+
+	w.parser.parse(data, func(text []byte) {
+		if w.err == nil {
+			_, w.err = w.writer.Write(text)
+		}
+	}, func([]byte) {})
+
+	if w.err != nil {
+		return 0, w.err
+	}
+
+	return len(data), nil
+}
@@ -0,0 +1,140 @@
+package ansi
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []Segment
+	}{
+		{
+			name:     "plain text",
+			input:    "no colors here",
+			expected: []Segment{{Text: "no colors here"}},
+		},
+		{
+			name:  "colors and reset",
+			input: "\x1b[1;31mERROR\x1b[0m done",
+			expected: []Segment{
+				{Text: "ERROR", Style: Style{Foreground: ColorRed, Bold: true}},
+				{Text: " done"},
+			},
+		},
+		{
+			name:  "bright background",
+			input: "\x1b[102mok",
+			expected: []Segment{
+				{Text: "ok", Style: Style{Background: ColorBrightGreen}},
+			},
+		},
+		{
+			name:  "256 and 24-bit colors",
+			input: "\x1b[38;5;196mred\x1b[38;2;0;0;238mblue",
+			expected: []Segment{
+				{Text: "red", Style: Style{Foreground: ColorBrightRed}},
+				{Text: "blue", Style: Style{Foreground: ColorBlue}},
+			},
+		},
+		{
+			name:  "same style is merged",
+			input: "\x1b[32mone\x1b[32m two",
+			expected: []Segment{
+				{Text: "one two", Style: Style{Foreground: ColorGreen}},
+			},
+		},
+		{
+			name:     "cursor movement is dropped",
+			input:    "\x1b[2K\x1b[1Gprogress",
+			expected: []Segment{{Text: "progress"}},
+		},
+		{
+			name:     "hyperlink is dropped",
+			input:    "\x1b]8;;https://example.com\x07link\x1b]8;;\x1b\\",
+			expected: []Segment{{Text: "link"}},
+		},
+		{
+			name:     "private sequence isn't SGR",
+			input:    "\x1b[?25mtext",
+			expected: []Segment{{Text: "text"}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual := Parse(test.input)
+			if len(actual) != len(test.expected) {
+				t.Fatalf("expected %v, got %v", test.expected, actual)
+			}
+
+			for i := range actual {
+				if actual[i] != test.expected[i] {
+					t.Errorf("expected %v, got %v", test.expected, actual)
+				}
+			}
+		})
+	}
+}
+
+// the text of segments is escaped when it's rendered, but the classes are used as they are
+var classesPattern = regexp.MustCompile(`^[a-z0-9 -]*$`)
+
+func TestParseOnlyProducesSafeClasses(t *testing.T) {
+	inputs := []string{
+		"\x1b[\"><script>alert(1)</script>m<b>bold</b>",
+		"\x1b[38;5;999;48;2;999;-1;<mtext",
+		"\x1b[7;38;5;255;48;5;0mtext",
+	}
+
+	for code := 0; code < 256; code++ {
+		inputs = append(inputs,
+			"\x1b["+strconv.Itoa(code)+"mtext",
+			"\x1b[38;5;"+strconv.Itoa(code)+"mtext",
+			"\x1b[48;2;"+strconv.Itoa(code)+";"+strconv.Itoa(255-code)+";"+strconv.Itoa(code/2)+"mtext",
+		)
+	}
+
+	for _, input := range inputs {
+		for _, segment := range Parse(input) {
+			if classes := segment.Style.Classes(); !classesPattern.MatchString(classes) {
+				t.Errorf("unexpected classes `%s` for input %q", classes, input)
+			}
+
+			if bytes.ContainsRune([]byte(segment.Text), escape) {
+				t.Errorf("escape sequence left in segment %q for input %q", segment.Text, input)
+			}
+		}
+	}
+}
+
+func TestParseKeepsMarkupAsText(t *testing.T) {
+	// the markup isn't interpreted, it's escaped by the template like any other text
+	segments := Parse("\x1b[31m<script>alert(1)</script>\x1b[0m")
+	if len(segments) != 1 || segments[0].Text != "<script>alert(1)</script>" {
+		t.Errorf("expected the markup as a single text segment, got %v", segments)
+	}
+}
+
+func TestStripWriterSplitSequences(t *testing.T) {
+	var output bytes.Buffer
+	writer := NewStripWriter(&output)
+
+	for _, data := range []string{"start \x1b", "[1;3", "1mred\x1b]0;tit", "le\x07 end\n"} {
+		if _, err := writer.Write([]byte(data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if expected := "start red end\n"; output.String() != expected {
+		t.Errorf("expected %q, got %q", expected, output.String())
+	}
+
+	if actual := Strip("\x1b[1mbold\x1b[0m"); actual != "bold" {
+		t.Errorf("expected %q, got %q", "bold", actual)
+	}
+}
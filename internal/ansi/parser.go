@@ -0,0 +1,106 @@
+// Package ansi parses ANSI escape sequences found in logs captured from terminals.
+package ansi
+
+const escape = 0x1b
+
+// maximum number of parameter bytes of a control sequence, longer sequences are dropped
+const maxParamsLength = 64
+
+type parserState uint8
+
+const (
+	stateText parserState = iota
+	stateEscape
+	stateControlSequence
+	stateOperatingSystemCommand
+	stateOperatingSystemCommandEscape
+)
+
+// parser is a state machine that splits text into plain text and escape sequences. The
+// state is kept between calls, so sequences can be split across multiple calls.
+type parser struct {
+	state  parserState
+	params []byte
+	// isSgr is false for control sequences that can't be SGR sequences
+	isSgr bool
+}
+
+// parse calls onText with every run of plain text and onSgr with the parameters of every
+// SGR (Select Graphic Rendition) sequence. All other escape sequences are dropped.
+func (p *parser) parse(data []byte, onText func([]byte), onSgr func([]byte)) {
+	start := 0
+
+	for i, b := range data {
+		switch p.state {
+		case stateText:
+			if b == escape {
+				if i > start {
+					onText(data[start:i])
+				}
+
+				p.state = stateEscape
+			}
+		case stateEscape:
+			switch b {
+			case '[':
+				p.state = stateControlSequence
+				p.params = p.params[:0]
+				p.isSgr = true
+			case ']':
+				p.state = stateOperatingSystemCommand
+			default:
+				// two byte sequences like ESC c
+				p.state = stateText
+				start = i + 1
+			}
+		case stateControlSequence:
+			switch {
+			case b >= 0x40 && b <= 0x7e:
+				// final byte
+				if b == 'm' && p.isSgr {
+					onSgr(p.params)
+				}
+
+				p.state = stateText
+				start = i + 1
+			case b >= 0x30 && b <= 0x3f:
+				// parameter bytes, private parameters like `?` aren't used by SGR sequences
+				if b >= '<' || len(p.params) >= maxParamsLength {
+					p.isSgr = false
+				} else {
+					p.params = append(p.params, b)
+				}
+			case b >= 0x20 && b <= 0x2f:
+				// intermediate bytes
+				p.isSgr = false
+			default:
+				// invalid sequence, the byte is kept as text
+				p.state = stateText
+				start = i
+			}
+		case stateOperatingSystemCommand:
+			switch b {
+			case 0x07:
+				p.state = stateText
+				start = i + 1
+			case escape:
+				p.state = stateOperatingSystemCommandEscape
+			case '\n':
+				// unterminated command, don't swallow the following lines
+				p.state = stateText
+				start = i
+			}
+		case stateOperatingSystemCommandEscape:
+			if b == '\\' {
+				p.state = stateText
+				start = i + 1
+			} else {
+				p.state = stateOperatingSystemCommand
+			}
+		}
+	}
+
+	if p.state == stateText && start < len(data) {
+		onText(data[start:])
+	}
+}
@@ -0,0 +1,235 @@
+package ansi
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Color is one of the 16 colors of the terminal palette. The zero value is the default color.
+type Color uint8
+
+const (
+	ColorDefault Color = iota
+	ColorBlack
+	ColorRed
+	ColorGreen
+	ColorYellow
+	ColorBlue
+	ColorMagenta
+	ColorCyan
+	ColorWhite
+	ColorBrightBlack
+	ColorBrightRed
+	ColorBrightGreen
+	ColorBrightYellow
+	ColorBrightBlue
+	ColorBrightMagenta
+	ColorBrightCyan
+	ColorBrightWhite
+)
+
+// paletteColor returns the color with the palette index between 0 and 15.
+func paletteColor(index int) Color {
+	return Color(index + 1)
+}
+
+type rgb struct {
+	r, g, b int
+}
+
+// the xterm palette, used to find the closest palette color for 256 and 24-bit colors
+var palette = [16]rgb{
+	{0, 0, 0}, {205, 0, 0}, {0, 205, 0}, {205, 205, 0},
+	{0, 0, 238}, {205, 0, 205}, {0, 205, 205}, {229, 229, 229},
+	{127, 127, 127}, {255, 0, 0}, {0, 255, 0}, {255, 255, 0},
+	{92, 92, 255}, {255, 0, 255}, {0, 255, 255}, {255, 255, 255},
+}
+
+func closestColor(color rgb) Color {
+	closest, closestDistance := 0, -1
+	for i, candidate := range palette {
+		dr, dg, db := candidate.r-color.r, candidate.g-color.g, candidate.b-color.b
+		distance := dr*dr + dg*dg + db*db
+		if closestDistance == -1 || distance < closestDistance {
+			closest, closestDistance = i, distance
+		}
+	}
+
+	return paletteColor(closest)
+}
+
+var cubeLevels = [6]int{0, 95, 135, 175, 215, 255}
+
+// extendedColor converts a color of the 256 color palette.
+func extendedColor(index int) Color {
+	switch {
+	case index < 16:
+		return paletteColor(index)
+	case index < 232:
+		index -= 16
+		return closestColor(rgb{cubeLevels[index/36], cubeLevels[(index/6)%6], cubeLevels[index%6]})
+	default:
+		gray := 8 + (index-232)*10
+		return closestColor(rgb{gray, gray, gray})
+	}
+}
+
+// Style is the graphic rendition of text. Only the 16 palette colors are supported,
+// 256 and 24-bit colors are converted to the closest palette color so that every style
+// can be expressed with a fixed set of CSS classes.
+type Style struct {
+	Foreground    Color
+	Background    Color
+	Bold          bool
+	Dim           bool
+	Italic        bool
+	Underline     bool
+	Inverse       bool
+	Strikethrough bool
+}
+
+func (s Style) IsDefault() bool {
+	return s == Style{}
+}
+
+// Classes returns the CSS classes of the style.
+func (s Style) Classes() string {
+	var classes []string
+
+	foreground, background := s.Foreground, s.Background
+	if s.Inverse {
+		classes = append(classes, "ansi-inverse")
+		foreground, background = background, foreground
+	}
+
+	if foreground != ColorDefault {
+		classes = append(classes, "ansi-fg-"+strconv.Itoa(int(foreground)-1))
+	}
+
+	if background != ColorDefault {
+		classes = append(classes, "ansi-bg-"+strconv.Itoa(int(background)-1))
+	}
+
+	if s.Bold {
+		classes = append(classes, "ansi-bold")
+	}
+
+	if s.Dim {
+		classes = append(classes, "ansi-dim")
+	}
+
+	if s.Italic {
+		classes = append(classes, "ansi-italic")
+	}
+
+	if s.Underline {
+		classes = append(classes, "ansi-underline")
+	}
+
+	if s.Strikethrough {
+		classes = append(classes, "ansi-strikethrough")
+	}
+
+	return strings.Join(classes, " ")
+}
+
+// apply updates the style with the parameters of an SGR sequence like `1;31`.
+func (s *Style) apply(params []byte) {
+	codes := parseParams(params)
+
+	for i := 0; i < len(codes); i++ {
+		code := codes[i]
+		switch {
+		case code == 0:
+			*s = Style{}
+		case code == 1:
+			s.Bold = true
+		case code == 2:
+			s.Dim = true
+		case code == 3:
+			s.Italic = true
+		case code == 4:
+			s.Underline = true
+		case code == 7:
+			s.Inverse = true
+		case code == 9:
+			s.Strikethrough = true
+		case code == 21 || code == 22:
+			s.Bold = false
+			s.Dim = false
+		case code == 23:
+			s.Italic = false
+		case code == 24:
+			s.Underline = false
+		case code == 27:
+			s.Inverse = false
+		case code == 29:
+			s.Strikethrough = false
+		case code >= 30 && code <= 37:
+			s.Foreground = paletteColor(code - 30)
+		case code == 38:
+			color, consumed := parseExtendedColor(codes[i+1:])
+			s.Foreground = color
+			i += consumed
+		case code == 39:
+			s.Foreground = ColorDefault
+		case code >= 40 && code <= 47:
+			s.Background = paletteColor(code - 40)
+		case code == 48:
+			color, consumed := parseExtendedColor(codes[i+1:])
+			s.Background = color
+			i += consumed
+		case code == 49:
+			s.Background = ColorDefault
+		case code >= 90 && code <= 97:
+			s.Foreground = paletteColor(code - 90 + 8)
+		case code >= 100 && code <= 107:
+			s.Background = paletteColor(code - 100 + 8)
+		}
+	}
+}
+
+// parseParams splits the parameters at `;` and `:`, empty parameters are 0.
+func parseParams(params []byte) []int {
+	codes := make([]int, 0, 4)
+
+	code := 0
+	for _, b := range params {
+		if b == ';' || b == ':' {
+			codes = append(codes, code)
+			code = 0
+			continue
+		}
+
+		if b >= '0' && b <= '9' && code < 1<<16 {
+			code = code*10 + int(b-'0')
+		}
+	}
+
+	return append(codes, code)
+}
+
+// parseExtendedColor parses the arguments of `38` and `48`, either `5;n` for the 256 color
+// palette or `2;r;g;b` for 24-bit colors. It returns the number of consumed arguments.
+func parseExtendedColor(args []int) (Color, int) {
+	if len(args) == 0 {
+		return ColorDefault, 0
+	}
+
+	switch args[0] {
+	case 5:
+		if len(args) < 2 || args[1] > 255 {
+			return ColorDefault, len(args)
+		}
+
+		return extendedColor(args[1]), 2
+	case 2:
+		if len(args) < 4 {
+			return ColorDefault, len(args)
+		}
+
+		return closestColor(rgb{args[1], args[2], args[3]}), 4
+	default:
+		return ColorDefault, 1
+	}
+}
@@ -0,0 +1,281 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/httplog/v2"
+	"github.com/oklog/ulid/v2"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"simple-log-store/internal/config"
+	"simple-log-store/internal/logs"
+	"simple-log-store/internal/redis"
+	"simple-log-store/internal/storage"
+	"simple-log-store/internal/utils"
+	"simple-log-store/internal/views"
+	"strconv"
+	"time"
+)
+
+// number of log bundles shown in each list of the admin dashboard
+const adminListLimit = 20
+
+// log bundles that expire within this duration are shown on the admin dashboard
+const adminExpiringWithin = 48 * time.Hour
+
+// maximum number of days a log bundle can be extended by with a single request
+const maxExtensionDays = 365
+
+// query parameters used to show the result of an action on the admin dashboard
+const (
+	adminDoneParam   = "done"
+	adminBundleParam = "bundle"
+)
+
+type adminHandler struct {
+	logRetentionDuration time.Duration
+
+	storageService *storage.Service
+	redisService   *redis.Service
+}
+
+func registerAdminHandler(r chi.Router, appConfig *config.AppConfig, storageService *storage.Service, redisService *redis.Service) {
+	h := &adminHandler{
+		logRetentionDuration: appConfig.LogRetentionDuration,
+
+		storageService: storageService,
+		redisService:   redisService,
+	}
+
+	r.Route("/admin", func(r chi.Router) {
+		r.Use(contentSecurityPolicy)
+		r.Use(requireAdmin(appConfig.AdminTokens))
+
+		r.Get("/", h.viewDashboard)
+
+		r.Route("/bundle/{logBundleId}", func(r chi.Router) {
+			r.Use(requireSameOrigin)
+			r.Use(idCtx)
+			r.Post("/delete", h.deleteBundle)
+			r.Post("/pin", h.pinBundle)
+			r.Post("/unpin", h.unpinBundle)
+			r.Post("/extend", h.extendBundle)
+		})
+	})
+}
+
+// getAdminBundles converts the log bundles for the dashboard and adds their expiry.
+func (h *adminHandler) getAdminBundles(r *http.Request, bundles []redis.ListedBundle) []views.AdminBundle {
+	res := make([]views.AdminBundle, len(bundles))
+	for i, bundle := range bundles {
+		res[i] = views.AdminBundle{LogBundleId: bundle.LogBundleId, LogBundle: bundle.LogBundle}
+
+		ttl, expires, err := h.redisService.GetLogBundleExpiry(r.Context(), bundle.LogBundleId)
+		if err != nil {
+			oplog := httplog.LogEntry(r.Context())
+			oplog.Warn("failed to get expiry of log bundle", slog.String("logBundleId", bundle.LogBundleId.String()), utils.ErrAttr(err))
+			res[i].ExpiresAt = ulid.Time(bundle.LogBundleId.Time()).Add(h.logRetentionDuration)
+		} else if expires {
+			res[i].ExpiresAt = time.Now().Add(ttl)
+		}
+	}
+
+	return res
+}
+
+// getActionMessage describes the action that redirected to the dashboard.
+func getActionMessage(query url.Values) string {
+	logBundleId, err := logs.ParseId(query.Get(adminBundleParam))
+	if err != nil {
+		return ""
+	}
+
+	switch done := query.Get(adminDoneParam); done {
+	case "deleted", "pinned", "unpinned", "extended":
+		return fmt.Sprintf("Log bundle %s was %s.", logBundleId.String(), done)
+	default:
+		return ""
+	}
+}
+
+func (h *adminHandler) viewDashboard(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	dashboard := views.AdminDashboard{
+		Message:        getActionMessage(r.URL.Query()),
+		ExpiringWithin: adminExpiringWithin,
+	}
+
+	fail := func(message string, err error) {
+		oplog := httplog.LogEntry(ctx)
+		oplog.Error(message, utils.ErrAttr(err))
+		writeInternalServerError(w)
+	}
+
+	samples, err := h.redisService.GetStorageUsageHistory(ctx)
+	if err != nil {
+		fail("failed to get storage usage history", err)
+		return
+	}
+
+	for _, sample := range samples {
+		dashboard.StorageUsage = append(dashboard.StorageUsage, views.StorageUsageSample(sample))
+	}
+
+	recent, err := h.redisService.QueryLogBundles(ctx, redis.BundleQuery{Limit: adminListLimit})
+	if err != nil {
+		fail("failed to query recent log bundles", err)
+		return
+	}
+
+	dashboard.RecentBundles = h.getAdminBundles(r, recent.Bundles)
+
+	expiring, err := h.redisService.GetExpiringLogBundles(ctx, time.Now().Add(adminExpiringWithin), adminListLimit)
+	if err != nil {
+		fail("failed to get expiring log bundles", err)
+		return
+	}
+
+	for _, bundle := range expiring {
+		dashboard.ExpiringBundles = append(dashboard.ExpiringBundles, views.AdminBundle{
+			LogBundleId: bundle.LogBundleId,
+			LogBundle:   bundle.LogBundle,
+			ExpiresAt:   bundle.ExpiresAt,
+		})
+	}
+
+	pinned, err := h.redisService.GetPinnedLogBundles(ctx)
+	if err != nil {
+		fail("failed to get pinned log bundles", err)
+		return
+	}
+
+	dashboard.PinnedBundles = h.getAdminBundles(r, pinned)
+
+	uploaders, err := h.redisService.GetTopUploaders(ctx, adminListLimit)
+	if err != nil {
+		fail("failed to get top uploaders", err)
+		return
+	}
+
+	for _, uploader := range uploaders {
+		dashboard.TopUploaders = append(dashboard.TopUploaders, views.UploaderCount(uploader))
+	}
+
+	failedCommits, err := h.redisService.GetFailedCommits(ctx, adminListLimit)
+	if err != nil {
+		fail("failed to get failed commits", err)
+		return
+	}
+
+	for _, failedCommit := range failedCommits {
+		dashboard.FailedCommits = append(dashboard.FailedCommits, views.FailedCommit(failedCommit))
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	err = views.Admin(dashboard).Render(ctx, w)
+	if err != nil {
+		oplog := httplog.LogEntry(ctx)
+		oplog.Error("error rendering templ component", utils.ErrAttr(err))
+		writeInternalServerError(w)
+	}
+}
+
+// redirectToDashboard shows the dashboard with the result of the action.
+func redirectToDashboard(w http.ResponseWriter, r *http.Request, logBundleId logs.LogBundleId, done string) {
+	query := url.Values{}
+	query.Set(adminDoneParam, done)
+	query.Set(adminBundleParam, logBundleId.String())
+	http.Redirect(w, r, "/admin/?"+query.Encode(), http.StatusSeeOther)
+}
+
+// writeActionError writes the response for an action that failed.
+func writeActionError(w http.ResponseWriter, r *http.Request, logBundleId logs.LogBundleId, message string, err error) {
+	if errors.Is(err, redis.ErrNotFound) {
+		http.NotFound(w, r)
+		return
+	}
+
+	oplog := httplog.LogEntry(r.Context())
+	oplog.Error(message, slog.String("logBundleId", logBundleId.String()), utils.ErrAttr(err))
+	writeInternalServerError(w)
+}
+
+// deleteBundle removes the log bundle and its log files, the same as `slsctl delete`.
+func (h *adminHandler) deleteBundle(w http.ResponseWriter, r *http.Request) {
+	logBundleId := r.Context().Value("id").(logs.LogBundleId)
+
+	logFileIds, err := h.redisService.GetLogBundle(r.Context(), logBundleId)
+	if err != nil {
+		writeActionError(w, r, logBundleId, "failed to get log bundle", err)
+		return
+	}
+
+	if err := h.redisService.DeleteLogBundle(r.Context(), logBundleId); err != nil {
+		writeActionError(w, r, logBundleId, "failed to delete log bundle", err)
+		return
+	}
+
+	for _, logFileId := range logFileIds {
+		if err := h.storageService.DeleteLogFile(logFileId); err != nil && !os.IsNotExist(err) {
+			writeActionError(w, r, logBundleId, fmt.Sprintf("failed to delete log file `%s`", logFileId.String()), err)
+			return
+		}
+	}
+
+	oplog := httplog.LogEntry(r.Context())
+	oplog.Info("deleted log bundle", slog.String("logBundleId", logBundleId.String()), slog.Int("logFileCount", len(logFileIds)))
+	redirectToDashboard(w, r, logBundleId, "deleted")
+}
+
+func (h *adminHandler) pinBundle(w http.ResponseWriter, r *http.Request) {
+	logBundleId := r.Context().Value("id").(logs.LogBundleId)
+
+	if err := h.redisService.PinLogBundle(r.Context(), logBundleId); err != nil {
+		writeActionError(w, r, logBundleId, "failed to pin log bundle", err)
+		return
+	}
+
+	redirectToDashboard(w, r, logBundleId, "pinned")
+}
+
+func (h *adminHandler) unpinBundle(w http.ResponseWriter, r *http.Request) {
+	logBundleId := r.Context().Value("id").(logs.LogBundleId)
+
+	if err := h.redisService.UnpinLogBundle(r.Context(), logBundleId); err != nil {
+		writeActionError(w, r, logBundleId, "failed to unpin log bundle", err)
+		return
+	}
+
+	redirectToDashboard(w, r, logBundleId, "unpinned")
+}
+
+func (h *adminHandler) extendBundle(w http.ResponseWriter, r *http.Request) {
+	logBundleId := r.Context().Value("id").(logs.LogBundleId)
+
+	days, err := strconv.Atoi(r.PostFormValue("days"))
+	if err != nil || days < 1 || days > maxExtensionDays {
+		http.Error(w, fmt.Sprintf("invalid `days`, expected a number between 1 and %d", maxExtensionDays), http.StatusBadRequest)
+		return
+	}
+
+	pinned, err := h.redisService.IsLogBundlePinned(r.Context(), logBundleId)
+	if err != nil {
+		writeActionError(w, r, logBundleId, "failed to check if log bundle is pinned", err)
+		return
+	}
+
+	if pinned {
+		http.Error(w, "pinned log bundles don't expire and can't be extended", http.StatusConflict)
+		return
+	}
+
+	if _, err := h.redisService.ExtendLogBundle(r.Context(), logBundleId, time.Duration(days)*24*time.Hour); err != nil {
+		writeActionError(w, r, logBundleId, "failed to extend log bundle", err)
+		return
+	}
+
+	redirectToDashboard(w, r, logBundleId, "extended")
+}
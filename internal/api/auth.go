@@ -0,0 +1,84 @@
+package api
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// getAuthorizationToken returns the token of a bearer `Authorization` header. Basic
+// authentication is accepted as well, with the token as the password and any user name,
+// because browsers support it without JavaScript.
+func getAuthorizationToken(r *http.Request) (string, bool) {
+	if _, password, ok := r.BasicAuth(); ok {
+		return password, password != ""
+	}
+
+	token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	token = strings.TrimSpace(token)
+	return token, found && token != ""
+}
+
+// findToken returns the index of the token, comparing all tokens in constant time. The
+// digests are compared because the comparison of values with different lengths returns early.
+func findToken(tokens []string, token string) int {
+	digest := sha256.Sum256([]byte(token))
+	index := -1
+	for i, candidate := range tokens {
+		candidateDigest := sha256.Sum256([]byte(candidate))
+		if subtle.ConstantTimeCompare(candidateDigest[:], digest[:]) == 1 {
+			index = i
+		}
+	}
+
+	return index
+}
+
+// requireAdmin only allows requests with one of the admin tokens. The endpoints are hidden
+// if no admin tokens are configured.
+func requireAdmin(adminTokens []string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if len(adminTokens) == 0 {
+				http.NotFound(w, r)
+				return
+			}
+
+			token, ok := getAuthorizationToken(r)
+			if !ok || findToken(adminTokens, token) == -1 {
+				w.Header().Set("WWW-Authenticate", `Basic realm="simple-log-store administration", charset="UTF-8"`)
+				http.Error(w, "a valid admin token is required", http.StatusUnauthorized)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// requireSameOrigin rejects requests sent by other sites. Browsers attach the credentials of
+// basic authentication to forms posted from any site, so the actions of the admin dashboard
+// would be open to cross-site request forgery without this check. Requests without the
+// headers set by browsers are allowed, they can't be forged by another site.
+func requireSameOrigin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the origin is `null` for forms posted with `Referrer-Policy: no-referrer`, so the
+		// fetch metadata is checked first
+		if site := r.Header.Get("Sec-Fetch-Site"); site != "" {
+			if site != "same-origin" {
+				http.Error(w, "cross-site requests are forbidden", http.StatusForbidden)
+				return
+			}
+		} else if origin := r.Header.Get("Origin"); origin != "" {
+			originUrl, err := url.Parse(origin)
+			if err != nil || originUrl.Host != r.Host {
+				http.Error(w, "cross-site requests are forbidden", http.StatusForbidden)
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,152 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/go-chi/httplog/v2"
+	"github.com/oklog/ulid/v2"
+	"net/http"
+	"net/url"
+	"simple-log-store/internal/logs"
+	"simple-log-store/internal/redis"
+	"simple-log-store/internal/utils"
+	"simple-log-store/pkg/types"
+	"strconv"
+	"time"
+)
+
+// number of log bundles returned by `GET /logs/bundles` if the limit isn't set
+const defaultListLimit = 50
+
+const maxListLimit = 500
+
+// parseBundleQuery parses the query parameters of `GET /logs/bundles`.
+func parseBundleQuery(query url.Values) (redis.BundleQuery, error) {
+	res := redis.BundleQuery{
+		Uploader: query.Get(types.ListUploaderParam),
+		Limit:    defaultListLimit,
+	}
+
+	var labels logs.BundleLabels
+	for _, tag := range query[types.ListTagParam] {
+		if err := labels.AddTag(tag); err != nil {
+			return res, err
+		}
+	}
+
+	res.Tags = labels.Tags
+
+	parseTime := func(param string) (time.Time, error) {
+		value := query.Get(param)
+		if value == "" {
+			return time.Time{}, nil
+		}
+
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return parsed, fmt.Errorf("invalid `%s`, expected an RFC 3339 time like `2024-01-01T00:00:00Z`", param)
+		}
+
+		return parsed, nil
+	}
+
+	parseSize := func(param string) (uint64, error) {
+		value := query.Get(param)
+		if value == "" {
+			return 0, nil
+		}
+
+		parsed, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid `%s`, expected a number of bytes", param)
+		}
+
+		return parsed, nil
+	}
+
+	var err error
+	if res.From, err = parseTime(types.ListFromParam); err != nil {
+		return res, err
+	}
+
+	if res.To, err = parseTime(types.ListToParam); err != nil {
+		return res, err
+	}
+
+	if res.MinSize, err = parseSize(types.ListMinSizeParam); err != nil {
+		return res, err
+	}
+
+	if res.MaxSize, err = parseSize(types.ListMaxSizeParam); err != nil {
+		return res, err
+	}
+
+	if cursor := query.Get(types.ListCursorParam); cursor != "" {
+		if res.Cursor, err = logs.ParseId(cursor); err != nil {
+			return res, fmt.Errorf("invalid `%s`", types.ListCursorParam)
+		}
+	}
+
+	if limit := query.Get(types.ListLimitParam); limit != "" {
+		parsed, err := strconv.Atoi(limit)
+		if err != nil || parsed < 1 || parsed > maxListLimit {
+			return res, fmt.Errorf("invalid `%s`, expected a number between 1 and %d", types.ListLimitParam, maxListLimit)
+		}
+
+		res.Limit = parsed
+	}
+
+	return res, nil
+}
+
+func (h *logsHandler) listBundles(w http.ResponseWriter, r *http.Request) {
+	query, err := parseBundleQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	page, err := h.redisService.QueryLogBundles(r.Context(), query)
+	if err != nil {
+		oplog := httplog.LogEntry(r.Context())
+		oplog.Error("failed to query log bundles", utils.ErrAttr(err))
+		writeInternalServerError(w)
+		return
+	}
+
+	res := types.BundleListResponse{
+		Bundles: make([]types.BundleSummary, len(page.Bundles)),
+	}
+
+	for i, bundle := range page.Bundles {
+		res.Bundles[i] = types.BundleSummary{
+			BundleId:  bundle.LogBundleId,
+			CreatedAt: ulid.Time(bundle.LogBundleId.Time()).UTC(),
+			BundleDetailsResponse: types.BundleDetailsResponse{
+				FileIds:     bundle.LogFileIds,
+				Title:       bundle.Title,
+				Description: bundle.Description,
+				Tags:        bundle.Tags,
+			},
+			Uploader: bundle.Uploader,
+			Size:     bundle.Size,
+		}
+	}
+
+	var zeroId logs.LogBundleId
+	if page.NextCursor != zeroId {
+		res.NextCursor = page.NextCursor.String()
+	}
+
+	jsonBytes, err := json.Marshal(res)
+	if err != nil {
+		oplog := httplog.LogEntry(r.Context())
+		oplog.Error("unexpected error while marshaling log bundles", utils.ErrAttr(err))
+		writeInternalServerError(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	_, _ = w.Write(jsonBytes)
+}
@@ -13,6 +13,10 @@ func writeInternalServerError(w http.ResponseWriter) {
 	http.Error(w, "something went wrong", http.StatusInternalServerError)
 }
 
+func writeShuttingDown(w http.ResponseWriter) {
+	http.Error(w, "the server is shutting down", http.StatusServiceUnavailable)
+}
+
 func idCtx(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		var idInput string
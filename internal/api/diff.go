@@ -0,0 +1,145 @@
+package api
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"simple-log-store/internal/diff"
+	"simple-log-store/internal/logs"
+	"simple-log-store/internal/storage"
+	"simple-log-store/internal/views"
+	"strconv"
+)
+
+// query parameters of the diff view
+const (
+	leftParam    = "left"
+	rightParam   = "right"
+	ignoreParam  = "ignore"
+	contextParam = "context"
+)
+
+// values of the `ignore` query parameter
+const (
+	ignoreTimestamps = "timestamps"
+	ignoreIds        = "ids"
+	ignoreNothing    = "none"
+)
+
+// number of unchanged lines shown around changes
+const defaultDiffContext = 3
+
+// maximum number of different lines before the diff gives up on finding the shortest edit script
+const maxDiffDifferences = 2000
+
+func (h *frontendHandler) viewDiff(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	leftInput, rightInput := query.Get(leftParam), query.Get(rightParam)
+
+	page := views.DiffPage{
+		LeftInput:  leftInput,
+		RightInput: rightInput,
+		Context:    defaultDiffContext,
+	}
+
+	// timestamps and IDs are ignored unless the form says otherwise
+	if ignore, ok := query[ignoreParam]; ok {
+		for _, value := range ignore {
+			switch value {
+			case ignoreTimestamps:
+				page.Options.IgnoreTimestamps = true
+			case ignoreIds:
+				page.Options.IgnoreIds = true
+			}
+		}
+	} else {
+		page.Options = diff.Options{IgnoreTimestamps: true, IgnoreIds: true}
+	}
+
+	if contextInput := query.Get(contextParam); contextInput != "" {
+		if contextInput == "all" {
+			page.Context = -1
+		} else {
+			parsed, err := strconv.Atoi(contextInput)
+			if err != nil || parsed < 0 {
+				http.Error(w, "query parameter `context` must be a non-negative number or `all`", http.StatusBadRequest)
+				return
+			}
+
+			page.Context = parsed
+		}
+	}
+
+	if leftInput == "" || rightInput == "" {
+		h.render(views.Diff(page), w, r)
+		return
+	}
+
+	var err error
+	if page.Left, err = logs.ParseId(leftInput); err != nil {
+		http.Error(w, "query parameter `left` must be a log file ID", http.StatusBadRequest)
+		return
+	}
+
+	if page.Right, err = logs.ParseId(rightInput); err != nil {
+		http.Error(w, "query parameter `right` must be a log file ID", http.StatusBadRequest)
+		return
+	}
+
+	leftLines, err := h.readAllLines(r.Context(), page.Left)
+	if err != nil {
+		h.writeLogFileError(w, r, page.Left, err)
+		return
+	}
+
+	rightLines, err := h.readAllLines(r.Context(), page.Right)
+	if err != nil {
+		h.writeLogFileError(w, r, page.Right, err)
+		return
+	}
+
+	normalize := func(lines []storage.LogFileLine) []string {
+		res := make([]string, len(lines))
+		for i, line := range lines {
+			res[i] = diff.Normalize(line.Text, page.Options)
+		}
+
+		return res
+	}
+
+	edits, complete := diff.Lines(normalize(leftLines.Lines), normalize(rightLines.Lines), maxDiffDifferences)
+	rows := diff.SideBySide(edits)
+
+	page.HasDiff = true
+	page.IsComplete = complete
+	page.LeftLines = toTextLines(leftLines.Lines)
+	page.RightLines = toTextLines(rightLines.Lines)
+	page.Sections = diff.Sections(rows, page.Context)
+
+	for _, row := range rows {
+		if row.Kind != diff.RowEqual {
+			page.ChangedRows += 1
+		}
+	}
+
+	h.render(views.Diff(page), w, r)
+}
+
+// readAllLines reads all lines of the log file, decoded to UTF-8.
+func (h *frontendHandler) readAllLines(ctx context.Context, logFileId logs.LogFileId) (storage.LogFileLines, error) {
+	encoding, err := h.indexService.GetLogFileEncoding(ctx, logFileId)
+	if err != nil {
+		return storage.LogFileLines{}, err
+	}
+
+	return h.storageService.ReadLogFileLines(logFileId, encoding, 0, math.MaxInt, nil)
+}
+
+func toTextLines(lines []storage.LogFileLine) []string {
+	res := make([]string, len(lines))
+	for i, line := range lines {
+		res[i] = line.Text
+	}
+
+	return res
+}
@@ -2,41 +2,90 @@ package api
 
 import (
 	"errors"
+	"fmt"
 	"github.com/a-h/templ"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/httplog/v2"
+	"github.com/oklog/ulid/v2"
 	"log/slog"
 	"net/http"
+	"os"
+	"simple-log-store/internal/config"
+	"simple-log-store/internal/index"
+	"simple-log-store/internal/jsonlines"
 	"simple-log-store/internal/logs"
 	"simple-log-store/internal/redis"
 	"simple-log-store/internal/storage"
 	"simple-log-store/internal/utils"
 	"simple-log-store/internal/views"
+	"simple-log-store/pkg/types"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type frontendHandler struct {
+	logRetentionDuration time.Duration
+	singleFileLimit      uint64
+	maxFileCount         uint16
+	hasUploadKeys        bool
+	requireUploadKey     bool
+	adminTokens          []string
+
 	storageService *storage.Service
 	redisService   *redis.Service
+	indexService   *index.Service
 }
 
-func registerFrontendHandler(r chi.Router, storageService *storage.Service, redisService *redis.Service) {
+func registerFrontendHandler(r chi.Router, appConfig *config.AppConfig, storageService *storage.Service, redisService *redis.Service, indexService *index.Service) {
 	h := &frontendHandler{
+		logRetentionDuration: appConfig.LogRetentionDuration,
+		singleFileLimit:      appConfig.SingleFileSizeLimit,
+		maxFileCount:         appConfig.MaxFileCount,
+		hasUploadKeys:        len(appConfig.UploadKeys) != 0,
+		requireUploadKey:     appConfig.RequireUploadKey,
+		adminTokens:          appConfig.AdminTokens,
+
 		storageService: storageService,
 		redisService:   redisService,
+		indexService:   indexService,
 	}
 
 	r.Route("/view", func(r chi.Router) {
-		r.Get("/", func(w http.ResponseWriter, r *http.Request) {
-			http.NotFound(w, r)
-		})
+		r.Use(contentSecurityPolicy)
+
+		r.Get("/", h.viewUpload)
+
+		r.Get("/diff", h.viewDiff)
+		// the signatures link to log bundles of all uploaders
+		r.With(requireAdmin(h.adminTokens)).Get("/signatures", h.viewSignatures)
 
 		r.Route("/bundle/{logBundleId}", func(r chi.Router) {
 			r.Use(idCtx)
 			r.Get("/", h.viewBundle)
+			r.Get("/timeline", h.viewTimeline)
+		})
+
+		r.Route("/file/{logFileId}", func(r chi.Router) {
+			r.Use(idCtx)
+			r.Get("/", h.viewFile)
+			r.Get("/lines", h.viewFileLines)
 		})
 	})
 }
 
+// only allow resources from the same origin, inline scripts and styles are forbidden
+const contentSecurityPolicyValue = "default-src 'none'; script-src 'self'; style-src 'self'; img-src 'self' data:; connect-src 'self'; base-uri 'none'; form-action 'self'; frame-ancestors 'none'"
+
+func contentSecurityPolicy(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Security-Policy", contentSecurityPolicyValue)
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.Header().Set("Referrer-Policy", "no-referrer")
+		next.ServeHTTP(w, r)
+	})
+}
+
 func (h *frontendHandler) render(component templ.Component, w http.ResponseWriter, r *http.Request) {
 	err := component.Render(r.Context(), w)
 	if err != nil {
@@ -46,12 +95,339 @@ func (h *frontendHandler) render(component templ.Component, w http.ResponseWrite
 	}
 }
 
+func (h *frontendHandler) viewUpload(w http.ResponseWriter, r *http.Request) {
+	h.render(views.Upload(h.maxFileCount, h.singleFileLimit, h.hasUploadKeys, h.requireUploadKey), w, r)
+}
+
 func (h *frontendHandler) viewBundle(w http.ResponseWriter, r *http.Request) {
 	logBundleId := r.Context().Value("id").(logs.LogBundleId)
 
+	logBundle, err := h.redisService.GetLogBundleRecord(r.Context(), logBundleId)
+	if err != nil {
+		if errors.Is(err, redis.ErrNotFound) {
+			h.render(views.NotFound(logBundleId), w, r)
+			return
+		}
+
+		oplog := httplog.LogEntry(r.Context())
+		oplog.Error("unexpected error while getting log bundle from redis", slog.String("logBundleId", logBundleId.String()), utils.ErrAttr(err))
+		writeInternalServerError(w)
+		return
+	}
+
+	logFileIds := logBundle.LogFileIds
+	files := make([]views.BundleFile, len(logFileIds))
+	for i, logFileId := range logFileIds {
+		files[i].LogFileId = logFileId
+
+		metadata, err := h.indexService.GetLogFileMetadata(r.Context(), logFileId)
+		if err != nil {
+			// the bundle is still usable without the metadata
+			oplog := httplog.LogEntry(r.Context())
+			oplog.Warn("failed to get metadata of log file", slog.String("logFileId", logFileId.String()), utils.ErrAttr(err))
+			continue
+		}
+
+		files[i].Metadata = metadata
+		files[i].HasMetadata = true
+	}
+
+	// files that haven't been committed yet don't have a size
+	for i, logFileId := range logFileIds {
+		fileInfo, err := h.storageService.StatLogFile(logFileId)
+		if err != nil {
+			continue
+		}
+
+		files[i].Size = uint64(fileInfo.Size)
+		files[i].HasSize = true
+	}
+
+	bundle := views.BundleInfo{
+		LogBundleId: logBundleId,
+		Labels:      logBundle.BundleLabels,
+		UploadedAt:  ulid.Time(logBundleId.Time()),
+	}
+
+	ttl, expires, err := h.redisService.GetLogBundleExpiry(r.Context(), logBundleId)
+	if err != nil {
+		oplog := httplog.LogEntry(r.Context())
+		oplog.Warn("failed to get expiry of log bundle", slog.String("logBundleId", logBundleId.String()), utils.ErrAttr(err))
+		bundle.ExpiresAt = bundle.UploadedAt.Add(h.logRetentionDuration)
+	} else if expires {
+		bundle.ExpiresAt = time.Now().Add(ttl)
+	}
+
+	var exceptions []views.BundleException
+	exceptionIndices := make(map[string]int)
+
+	filesStackTraces, err := h.indexService.GetLogFilesStackTraces(r.Context(), logFileIds)
+	if err != nil {
+		// the bundle is still usable without the exceptions
+		oplog := httplog.LogEntry(r.Context())
+		oplog.Warn("failed to get stack traces of log bundle", slog.String("logBundleId", logBundleId.String()), utils.ErrAttr(err))
+	}
+
+	for i, stackTraces := range filesStackTraces {
+		logFileId := logFileIds[i]
+		for _, stackTrace := range stackTraces {
+			if i, ok := exceptionIndices[stackTrace.Fingerprint]; ok {
+				exceptions[i].Count += 1
+				continue
+			}
+
+			exceptionIndices[stackTrace.Fingerprint] = len(exceptions)
+			exceptions = append(exceptions, views.BundleException{
+				StackTrace: stackTrace,
+				LogFileId:  logFileId,
+				Count:      1,
+			})
+		}
+	}
+
+	h.render(views.Bundle(bundle, files, exceptions, len(h.adminTokens) != 0), w, r)
+}
+
+// number of lines on a single page of the file viewer
+const linesPerPage = 1000
+
+// query parameters of the file viewer
+const (
+	pageParam    = "page"
+	levelParam   = "level"
+	filterParam  = "filter"
+	columnsParam = "columns"
+	modeParam    = "mode"
+)
+
+// getLogFilePage reads the page of the log file requested with the `page` query parameter.
+// The lines can be filtered by a minimum level with `level` and by fields of JSON lines
+// with `filter`. It writes an error response and returns false if the page can't be read.
+func (h *frontendHandler) getLogFilePage(w http.ResponseWriter, r *http.Request) (views.LogFilePage, bool) {
+	logFileId := r.Context().Value("id").(logs.LogFileId)
+	query := r.URL.Query()
+
+	pageNumber := 1
+	if pageInput := query.Get(pageParam); pageInput != "" {
+		parsed, err := strconv.Atoi(pageInput)
+		if err != nil || parsed < 1 {
+			http.Error(w, "query parameter `page` must be a positive number", http.StatusBadRequest)
+			return views.LogFilePage{}, false
+		}
+
+		pageNumber = parsed
+	}
+
+	minLevel := logs.LevelUnknown
+	if levelInput := query.Get(levelParam); levelInput != "" {
+		parsed, ok := logs.ParseLevel(levelInput)
+		if !ok {
+			http.Error(w, "query parameter `level` must be one of trace, debug, info, warn, error or fatal", http.StatusBadRequest)
+			return views.LogFilePage{}, false
+		}
+
+		minLevel = parsed
+	}
+
+	filterInput := query.Get(filterParam)
+	fieldFilters, err := jsonlines.ParseFilters(filterInput)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("query parameter `filter` is invalid: %s", err), http.StatusBadRequest)
+		return views.LogFilePage{}, false
+	}
+
+	var columns []string
+	for _, value := range query[columnsParam] {
+		for _, column := range strings.Split(value, ",") {
+			if column = strings.TrimSpace(column); column != "" {
+				columns = append(columns, column)
+			}
+		}
+	}
+
+	metadata, err := h.indexService.GetLogFileMetadata(r.Context(), logFileId)
+	if err != nil {
+		h.writeLogFileError(w, r, logFileId, err)
+		return views.LogFilePage{}, false
+	}
+
+	if !metadata.IsText() {
+		// binaries aren't indexed, they are only downloaded
+		http.Redirect(w, r, fmt.Sprintf(types.FilePath, logFileId.String()), http.StatusSeeOther)
+		return views.LogFilePage{}, false
+	}
+
+	levels, err := h.indexService.GetLogFileLevels(r.Context(), logFileId)
+	if err != nil {
+		h.writeLogFileError(w, r, logFileId, err)
+		return views.LogFilePage{}, false
+	}
+
+	getLevel := func(lineNumber uint64) logs.Level {
+		if lineNumber > uint64(len(levels)) {
+			return logs.LevelUnknown
+		}
+
+		return levels[lineNumber-1]
+	}
+
+	var filter storage.LineFilter
+	if minLevel != logs.LevelUnknown || len(fieldFilters) != 0 {
+		filter = func(lineNumber uint64, line []byte) bool {
+			if getLevel(lineNumber) < minLevel {
+				return false
+			}
+
+			if len(fieldFilters) == 0 {
+				return true
+			}
+
+			entry, ok := jsonlines.Parse(line)
+			return ok && jsonlines.MatchesAll(entry, fieldFilters)
+		}
+	}
+
+	skip := uint64(pageNumber-1) * linesPerPage
+	lines, err := h.storageService.ReadLogFileLines(logFileId, metadata.Encoding, skip, linesPerPage, filter)
+	if err != nil {
+		h.writeLogFileError(w, r, logFileId, err)
+		return views.LogFilePage{}, false
+	}
+
+	pageCount := int((lines.MatchingLines + linesPerPage - 1) / linesPerPage)
+	if pageCount == 0 {
+		pageCount = 1
+	}
+
+	if pageNumber > pageCount {
+		query.Set(pageParam, strconv.Itoa(pageCount))
+		http.Redirect(w, r, fmt.Sprintf("%s?%s", r.URL.Path, query.Encode()), http.StatusFound)
+		return views.LogFilePage{}, false
+	}
+
+	// JSON lines are shown as a table unless the text view is requested
+	isTable := metadata.Format == logs.FormatJsonLines && query.Get(modeParam) != views.ModeText
+
+	var entries []jsonlines.Entry
+	pageLines := make([]views.LogLine, len(lines.Lines))
+	for i, line := range lines.Lines {
+		pageLines[i] = views.LogLine{
+			Number: line.Number,
+			Text:   line.Text,
+			Level:  getLevel(line.Number),
+		}
+
+		if !isTable {
+			continue
+		}
+
+		if entry, ok := jsonlines.Parse([]byte(line.Text)); ok {
+			pageLines[i].Entry = entry
+			entries = append(entries, entry)
+		}
+	}
+
+	// stack traces in JSON lines are part of a field and aren't folded
+	var stackTraces []logs.StackTrace
+	if !isTable && len(pageLines) != 0 {
+		allStackTraces, err := h.indexService.GetLogFileStackTraces(r.Context(), logFileId)
+		if err != nil {
+			h.writeLogFileError(w, r, logFileId, err)
+			return views.LogFilePage{}, false
+		}
+
+		firstLine, lastLine := pageLines[0].Number, pageLines[len(pageLines)-1].Number
+		for _, stackTrace := range allStackTraces {
+			if stackTrace.LastFrame >= firstLine && stackTrace.FirstFrame <= lastLine {
+				stackTraces = append(stackTraces, stackTrace)
+			}
+		}
+	}
+
+	availableColumns := jsonlines.AllKeys(entries)
+	if isTable && len(columns) == 0 {
+		columns = jsonlines.DefaultColumns(entries)
+		if len(columns) == 0 {
+			columns = availableColumns[:min(len(availableColumns), 3)]
+		}
+	}
+
+	query.Del(pageParam)
+
+	return views.LogFilePage{
+		LogFileId:        logFileId,
+		Page:             pageNumber,
+		PageCount:        pageCount,
+		PageSize:         linesPerPage,
+		Lines:            pageLines,
+		MinLevel:         minLevel,
+		Filter:           filterInput,
+		Format:           metadata.Format,
+		Redactions:       metadata.Redactions,
+		Encoding:         metadata.Encoding,
+		IsTable:          isTable,
+		Columns:          columns,
+		AvailableColumns: availableColumns,
+		StackTraces:      stackTraces,
+		Query:            query,
+		TotalLines:       lines.TotalLines,
+		MatchingLines:    lines.MatchingLines,
+	}, true
+}
+
+// writeLogFileError writes a not found page if the log file doesn't exist, and an internal
+// server error otherwise.
+func (h *frontendHandler) writeLogFileError(w http.ResponseWriter, r *http.Request, logFileId logs.LogFileId, err error) {
+	if os.IsNotExist(err) {
+		w.WriteHeader(http.StatusNotFound)
+		h.render(views.NotFound(logFileId), w, r)
+		return
+	}
+
+	oplog := httplog.LogEntry(r.Context())
+	oplog.Error("unexpected error while reading log file", slog.String("logFileId", logFileId.String()), utils.ErrAttr(err))
+	writeInternalServerError(w)
+}
+
+func (h *frontendHandler) viewFile(w http.ResponseWriter, r *http.Request) {
+	page, ok := h.getLogFilePage(w, r)
+	if !ok {
+		return
+	}
+
+	h.render(views.File(page), w, r)
+}
+
+func (h *frontendHandler) viewFileLines(w http.ResponseWriter, r *http.Request) {
+	page, ok := h.getLogFilePage(w, r)
+	if !ok {
+		return
+	}
+
+	h.render(views.LogLines(page, false), w, r)
+}
+
+// number of entries on a single page of the timeline
+const entriesPerPage = 500
+
+func (h *frontendHandler) viewTimeline(w http.ResponseWriter, r *http.Request) {
+	logBundleId := r.Context().Value("id").(logs.LogBundleId)
+
+	pageNumber := 1
+	if pageInput := r.URL.Query().Get(pageParam); pageInput != "" {
+		parsed, err := strconv.Atoi(pageInput)
+		if err != nil || parsed < 1 {
+			http.Error(w, "query parameter `page` must be a positive number", http.StatusBadRequest)
+			return
+		}
+
+		pageNumber = parsed
+	}
+
 	logFileIds, err := h.redisService.GetLogBundle(r.Context(), logBundleId)
 	if err != nil {
 		if errors.Is(err, redis.ErrNotFound) {
+			w.WriteHeader(http.StatusNotFound)
 			h.render(views.NotFound(logBundleId), w, r)
 			return
 		}
@@ -62,5 +438,133 @@ func (h *frontendHandler) viewBundle(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	h.render(views.Bundle(logBundleId, logFileIds), w, r)
+	timeline, err := h.indexService.BuildTimeline(r.Context(), logFileIds)
+	if err != nil {
+		oplog := httplog.LogEntry(r.Context())
+		oplog.Error("unexpected error while building timeline of log bundle", slog.String("logBundleId", logBundleId.String()), utils.ErrAttr(err))
+		writeInternalServerError(w)
+		return
+	}
+
+	pageCount := (len(timeline.Entries) + entriesPerPage - 1) / entriesPerPage
+	if pageCount == 0 {
+		pageCount = 1
+	}
+
+	if pageNumber > pageCount {
+		http.Redirect(w, r, fmt.Sprintf("%s?%s=%d", r.URL.Path, pageParam, pageCount), http.StatusFound)
+		return
+	}
+
+	first := (pageNumber - 1) * entriesPerPage
+	entries := timeline.Entries[first:min(first+entriesPerPage, len(timeline.Entries))]
+
+	// every file is read once for all lines of the entries on the page
+	lineNumbers := make([]map[uint64]struct{}, len(logFileIds))
+	for _, entry := range entries {
+		if lineNumbers[entry.FileIndex] == nil {
+			lineNumbers[entry.FileIndex] = make(map[uint64]struct{})
+		}
+
+		for i := uint64(0); i < entry.LineCount; i++ {
+			lineNumbers[entry.FileIndex][entry.FirstLine+i] = struct{}{}
+		}
+	}
+
+	fileLines := make([]map[uint64]views.LogLine, len(logFileIds))
+	for i, logFileId := range logFileIds {
+		if len(lineNumbers[i]) == 0 {
+			continue
+		}
+
+		levels, err := h.indexService.GetLogFileLevels(r.Context(), logFileId)
+		if err != nil {
+			h.writeLogFileError(w, r, logFileId, err)
+			return
+		}
+
+		encoding, err := h.indexService.GetLogFileEncoding(r.Context(), logFileId)
+		if err != nil {
+			h.writeLogFileError(w, r, logFileId, err)
+			return
+		}
+
+		lines, err := h.storageService.ReadLogFileLines(logFileId, encoding, 0, len(lineNumbers[i]), func(lineNumber uint64, _ []byte) bool {
+			_, ok := lineNumbers[i][lineNumber]
+			return ok
+		})
+
+		if os.IsNotExist(err) {
+			// the entries can be cached after the log file was removed
+			timeline.UnavailableFiles = append(timeline.UnavailableFiles, i)
+			continue
+		}
+
+		if err != nil {
+			h.writeLogFileError(w, r, logFileId, err)
+			return
+		}
+
+		fileLines[i] = make(map[uint64]views.LogLine, len(lines.Lines))
+		for _, line := range lines.Lines {
+			level := logs.LevelUnknown
+			if line.Number <= uint64(len(levels)) {
+				level = levels[line.Number-1]
+			}
+
+			fileLines[i][line.Number] = views.LogLine{
+				Number: line.Number,
+				Text:   line.Text,
+				Level:  level,
+			}
+		}
+	}
+
+	page := views.TimelinePage{
+		LogBundleId:  logBundleId,
+		LogFileIds:   logFileIds,
+		Page:         pageNumber,
+		PageCount:    pageCount,
+		TotalEntries: len(timeline.Entries),
+		Entries:      make([]views.TimelineEntry, len(entries)),
+	}
+
+	for _, fileIndex := range timeline.FilesWithoutTimestamps {
+		page.FilesWithoutTimestamps = append(page.FilesWithoutTimestamps, logFileIds[fileIndex])
+	}
+
+	for _, fileIndex := range timeline.UnavailableFiles {
+		page.UnavailableFiles = append(page.UnavailableFiles, logFileIds[fileIndex])
+	}
+
+	for i, entry := range entries {
+		page.Entries[i] = views.TimelineEntry{
+			FileIndex: entry.FileIndex,
+			Time:      entry.Time,
+			Lines:     make([]views.LogLine, 0, entry.LineCount),
+		}
+
+		for j := uint64(0); j < entry.LineCount; j++ {
+			if line, ok := fileLines[entry.FileIndex][entry.FirstLine+j]; ok {
+				page.Entries[i].Lines = append(page.Entries[i].Lines, line)
+			}
+		}
+	}
+
+	h.render(views.Timeline(page), w, r)
+}
+
+// number of signatures shown on the signatures page
+const signaturesLimit = 100
+
+func (h *frontendHandler) viewSignatures(w http.ResponseWriter, r *http.Request) {
+	signatures, err := h.redisService.ListSignatures(r.Context(), signaturesLimit)
+	if err != nil {
+		oplog := httplog.LogEntry(r.Context())
+		oplog.Error("unexpected error while listing signatures", utils.ErrAttr(err))
+		writeInternalServerError(w)
+		return
+	}
+
+	h.render(views.Signatures(signatures), w, r)
 }
@@ -0,0 +1,135 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/go-chi/chi/v5"
+	"net/http"
+	"simple-log-store/internal/config"
+	"simple-log-store/internal/redis"
+	"simple-log-store/internal/storage"
+	"time"
+)
+
+const readinessTimeout = time.Second * 5
+
+// Handler for the `/healthz` and `/readyz` endpoints.
+type healthHandler struct {
+	// minimum number of free bytes required to accept the largest possible upload
+	minFreeBytes uint64
+
+	storageService *storage.Service
+	redisService   *redis.Service
+}
+
+const (
+	healthStatusOk   = "ok"
+	healthStatusFail = "fail"
+)
+
+type healthCheck struct {
+	Status    string  `json:"status"`
+	Error     string  `json:"error,omitempty"`
+	Path      string  `json:"path,omitempty"`
+	FreeBytes *uint64 `json:"freeBytes,omitempty"`
+	Latency   string  `json:"latency,omitempty"`
+}
+
+type readinessResponse struct {
+	Status string                 `json:"status"`
+	Checks map[string]healthCheck `json:"checks"`
+}
+
+func registerHealthHandler(r chi.Router, appConfig *config.AppConfig, storageService *storage.Service, redisService *redis.Service) {
+	h := &healthHandler{
+		minFreeBytes:   appConfig.SingleFileSizeLimit * uint64(appConfig.MaxFileCount),
+		storageService: storageService,
+		redisService:   redisService,
+	}
+
+	r.Get("/healthz", h.healthz)
+	r.Get("/readyz", h.readyz)
+}
+
+func (h *healthHandler) healthz(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain")
+	w.Header().Set("Cache-Control", "no-store")
+	_, _ = w.Write([]byte(healthStatusOk))
+}
+
+func (h *healthHandler) readyz(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+	defer cancel()
+
+	res := readinessResponse{
+		Status: healthStatusOk,
+		Checks: map[string]healthCheck{
+			"redis":   h.checkRedis(ctx),
+			"staging": h.checkDirectory(h.storageService.CheckStagingDirectory()),
+			"storage": h.checkDirectory(h.storageService.CheckStorageDirectory()),
+		},
+	}
+
+	statusCode := http.StatusOK
+	for _, check := range res.Checks {
+		if check.Status != healthStatusOk {
+			res.Status = healthStatusFail
+			statusCode = http.StatusServiceUnavailable
+			break
+		}
+	}
+
+	jsonBytes, err := json.Marshal(res)
+	if err != nil {
+		writeInternalServerError(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(statusCode)
+	_, _ = w.Write(jsonBytes)
+}
+
+func (h *healthHandler) checkRedis(ctx context.Context) healthCheck {
+	start := time.Now()
+	err := h.redisService.Ping(ctx)
+
+	check := healthCheck{
+		Status:  healthStatusOk,
+		Latency: time.Since(start).String(),
+	}
+
+	if err != nil {
+		check.Status = healthStatusFail
+		check.Error = err.Error()
+	}
+
+	return check
+}
+
+func (h *healthHandler) checkDirectory(health storage.DirectoryHealth) healthCheck {
+	check := healthCheck{
+		Status: healthStatusOk,
+		Path:   health.Path,
+	}
+
+	if health.Err != nil {
+		check.Status = healthStatusFail
+		check.Error = health.Err.Error()
+		return check
+	}
+
+	// NOTE: free disk space is unknown on some platforms
+	if !health.HasFreeBytes {
+		return check
+	}
+
+	check.FreeBytes = &health.FreeBytes
+	if health.FreeBytes < h.minFreeBytes {
+		check.Status = healthStatusFail
+		check.Error = "not enough free disk space to accept an upload"
+	}
+
+	return check
+}
@@ -0,0 +1,91 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"simple-log-store/internal/logs"
+	"simple-log-store/pkg/types"
+	"strings"
+	"unicode/utf8"
+)
+
+// parseLabelHeaders returns the labels of the new log bundle set with headers.
+func parseLabelHeaders(header http.Header) (logs.BundleLabels, error) {
+	labels := logs.BundleLabels{
+		Title:       strings.TrimSpace(header.Get(types.TitleHeader)),
+		Description: strings.TrimSpace(header.Get(types.DescriptionHeader)),
+	}
+
+	for _, value := range header.Values(types.TagsHeader) {
+		if err := addTags(&labels, value); err != nil {
+			return labels, err
+		}
+	}
+
+	for _, value := range []string{labels.Title, labels.Description} {
+		if !utf8.ValidString(value) {
+			return labels, fmt.Errorf("labels must be valid UTF-8")
+		}
+	}
+
+	return labels, nil
+}
+
+// isLabelField returns true if the multipart part labels the log bundle instead of being a log file.
+func isLabelField(part *multipart.Part) bool {
+	if part.FileName() != "" {
+		return false
+	}
+
+	switch part.FormName() {
+	case types.TitleField, types.DescriptionField, types.TagField, types.TagsField:
+		return true
+	default:
+		return false
+	}
+}
+
+// readLabelField adds the value of the multipart field to the labels. Fields override the
+// title and description set with headers.
+func readLabelField(part *multipart.Part, labels *logs.BundleLabels) error {
+	// four bytes per character is the upper bound for UTF-8
+	data, err := io.ReadAll(io.LimitReader(part, logs.MaxDescriptionLength*4+1))
+	if err != nil {
+		return fmt.Errorf("failed to read field `%s`: %w", part.FormName(), err)
+	}
+
+	if len(data) > logs.MaxDescriptionLength*4 || !utf8.Valid(data) {
+		return fmt.Errorf("field `%s` is too long or not valid UTF-8", part.FormName())
+	}
+
+	value := strings.TrimSpace(string(data))
+
+	switch part.FormName() {
+	case types.TitleField:
+		labels.Title = value
+	case types.DescriptionField:
+		labels.Description = value
+	case types.TagField:
+		return labels.AddTag(value)
+	case types.TagsField:
+		return addTags(labels, value)
+	}
+
+	return nil
+}
+
+func addTags(labels *logs.BundleLabels, input string) error {
+	for _, tag := range strings.Split(input, ",") {
+		if strings.TrimSpace(tag) == "" {
+			continue
+		}
+
+		if err := labels.AddTag(tag); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -1,6 +1,8 @@
 package api
 
 import (
+	"archive/zip"
+	"compress/gzip"
 	"context"
 	"encoding/json"
 	"errors"
@@ -10,13 +12,26 @@ import (
 	"github.com/oklog/ulid/v2"
 	"io"
 	"log/slog"
+	"mime"
 	"net/http"
+	"net/url"
 	"os"
+	"path"
+	"regexp"
+	"simple-log-store/internal/ansi"
+	"simple-log-store/internal/charset"
 	"simple-log-store/internal/config"
+	"simple-log-store/internal/index"
 	"simple-log-store/internal/logs"
 	"simple-log-store/internal/redis"
 	"simple-log-store/internal/storage"
 	"simple-log-store/internal/utils"
+	"simple-log-store/internal/webhook"
+	"simple-log-store/pkg/types"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 )
 
@@ -26,21 +41,50 @@ type logsHandler struct {
 	maxFileCount       uint16
 	contentLengthLimit uint64
 
+	adminTokens []string
+	// upload keys and the names of their uploaders at the same index
+	uploadKeys       []string
+	uploaderNames    []string
+	requireUploadKey bool
+	publicUrl        string
+
 	storageService *storage.Service
 	redisService   *redis.Service
+	indexService   *index.Service
+	webhookService *webhook.Service
+	metrics        *apiMetrics
+
+	// logger is used by the commits, which finish after the request
+	logger          *slog.Logger
+	inFlightUploads *atomic.Int64
 }
 
-func registerLogsHandler(r chi.Router, appConfig *config.AppConfig, storageService *storage.Service, redisService *redis.Service) {
+func registerLogsHandler(r chi.Router, logger *slog.Logger, appConfig *config.AppConfig, storageService *storage.Service, redisService *redis.Service, indexService *index.Service, webhookService *webhook.Service, metrics *apiMetrics, inFlightUploads *atomic.Int64) {
 	h := &logsHandler{
 		singleFileLimit:    appConfig.SingleFileSizeLimit,
 		maxFileCount:       appConfig.MaxFileCount,
 		contentLengthLimit: appConfig.SingleFileSizeLimit * uint64(appConfig.MaxFileCount),
+		adminTokens:        appConfig.AdminTokens,
+		requireUploadKey:   appConfig.RequireUploadKey,
+		publicUrl:          strings.TrimSuffix(appConfig.PublicUrl, "/"),
 		storageService:     storageService,
 		redisService:       redisService,
+		indexService:       indexService,
+		webhookService:     webhookService,
+		metrics:            metrics,
+		logger:             logger.With(slog.String("handler", "logs")),
+		inFlightUploads:    inFlightUploads,
+	}
+
+	for name, key := range appConfig.UploadKeys {
+		h.uploaderNames = append(h.uploaderNames, name)
+		h.uploadKeys = append(h.uploadKeys, key)
 	}
 
 	r.Route("/logs", func(r chi.Router) {
 		r.Post("/", h.post)
+		r.With(requireAdmin(h.adminTokens)).Get("/bundles", h.listBundles)
+		r.With(requireAdmin(h.adminTokens)).Get("/webhooks/deliveries", h.listDeliveries)
 
 		r.Route("/file/{logFileId}", func(r chi.Router) {
 			r.Use(idCtx)
@@ -50,26 +94,64 @@ func registerLogsHandler(r chi.Router, appConfig *config.AppConfig, storageServi
 		r.Route("/bundle/{logBundleId}", func(r chi.Router) {
 			r.Use(idCtx)
 			r.Get("/", h.getBundle)
+			r.Get("/archive", h.getArchive)
+			r.Get("/search", h.search)
 		})
 	})
 }
 
 func (h *logsHandler) post(w http.ResponseWriter, r *http.Request) {
-	if r.ContentLength <= 0 {
-		http.Error(w, fmt.Sprintf("Content-Length must be set to a positive non-zero value!"), http.StatusLengthRequired)
+	h.inFlightUploads.Add(1)
+	defer h.inFlightUploads.Add(-1)
+
+	start := time.Now()
+	result := uploadResultError
+	defer func() {
+		h.metrics.uploads.WithLabelValues(result).Inc()
+		h.metrics.uploadDuration.Observe(time.Since(start).Seconds())
+	}()
+
+	if h.storageService.ShuttingDown() {
+		result = uploadResultRejected
+		writeShuttingDown(w)
 		return
 	}
 
-	if uint64(r.ContentLength) > h.contentLengthLimit {
+	switch {
+	case r.ContentLength == -1:
+		// chunked bodies have no Content-Length, they are rejected once they exceed the limit
+		r.Body = http.MaxBytesReader(w, r.Body, int64(h.contentLengthLimit))
+	case r.ContentLength == 0:
+		result = uploadResultRejected
+		http.Error(w, fmt.Sprintf("Content-Length must be set to a positive non-zero value!"), http.StatusLengthRequired)
+		return
+	case uint64(r.ContentLength) > h.contentLengthLimit:
+		result = uploadResultRejected
+		h.metrics.rejections.WithLabelValues(rejectionReasonContentLength).Inc()
 		http.Error(w, fmt.Sprintf("Content-Length of %d is over the limit of %d bytes", r.ContentLength, h.contentLengthLimit), http.StatusRequestEntityTooLarge)
 		return
 	}
 
+	uploader, ok := h.getUploader(r)
+	if !ok {
+		result = uploadResultRejected
+		http.Error(w, "a valid upload key is required", http.StatusUnauthorized)
+		return
+	}
+
+	labels, err := parseLabelHeaders(r.Header)
+	if err != nil {
+		result = uploadResultRejected
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	reader, err := r.MultipartReader()
 	if err != nil {
 		oplog := httplog.LogEntry(r.Context())
 		oplog.Error("failed to parse multipart form", utils.ErrAttr(err))
 
+		result = uploadResultRejected
 		w.Header().Set("Accept-Post", "multipart/form-data")
 		http.Error(w, "invalid multipart form", http.StatusUnsupportedMediaType)
 		return
@@ -77,34 +159,74 @@ func (h *logsHandler) post(w http.ResponseWriter, r *http.Request) {
 
 	fileCount := 0
 	logFileIds := make([]logs.LogFileId, h.maxFileCount)
+	var totalSize uint64
 
 	for {
-		if uint16(fileCount) >= h.maxFileCount {
-			http.Error(w, "you're not allowed to upload more than `%d` file(s)", http.StatusRequestEntityTooLarge)
-			return
-		}
-
 		part, err := reader.NextRawPart()
 		if err != nil {
 			if errors.Is(err, io.EOF) {
 				break
 			}
 
+			if h.rejectBodyTooLarge(w, err) {
+				result = uploadResultRejected
+				return
+			}
+
 			oplog := httplog.LogEntry(r.Context())
 			oplog.Error("unexpected error, expected EOF", utils.ErrAttr(err))
 			writeInternalServerError(w)
 			return
 		}
 
+		if isLabelField(part) {
+			if err := readLabelField(part, &labels); err != nil {
+				result = uploadResultRejected
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			continue
+		}
+
+		if uint16(fileCount) >= h.maxFileCount {
+			result = uploadResultRejected
+			h.metrics.rejections.WithLabelValues(rejectionReasonFileCount).Inc()
+			http.Error(w, fmt.Sprintf("you're not allowed to upload more than `%d` file(s)", h.maxFileCount), http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		var partReader io.Reader = part
+		if part.Header.Get("Content-Encoding") == "gzip" {
+			gzipReader, err := gzip.NewReader(part)
+			if err != nil {
+				result = uploadResultRejected
+				http.Error(w, "invalid gzip stream", http.StatusBadRequest)
+				return
+			}
+
+			partReader = gzipReader
+		}
+
 		logFileId := ulid.Make()
 		logFileIds[fileCount] = logFileId
 		fileCount += 1
 
-		err = h.storageService.StageLogFile(logFileId, part, h.singleFileLimit)
+		stagedLogFile, err := h.storageService.StageLogFile(logFileId, partReader, h.singleFileLimit)
 		if err != nil {
 			var fileTooLarge storage.FileTooLarge
+			var unredactable storage.UnredactableFile
 			if errors.As(err, &fileTooLarge) {
-				http.Error(w, fmt.Sprintf("`%d` bytes is over the single file limit of `%d` bytes", fileTooLarge.Actual, fileTooLarge.Actual), http.StatusRequestEntityTooLarge)
+				result = uploadResultRejected
+				h.metrics.rejections.WithLabelValues(rejectionReasonFileSize).Inc()
+				http.Error(w, fmt.Sprintf("`%d` bytes is over the single file limit of `%d` bytes", fileTooLarge.Actual, fileTooLarge.Limit), http.StatusRequestEntityTooLarge)
+				return
+			} else if errors.As(err, &unredactable) {
+				result = uploadResultRejected
+				http.Error(w, fmt.Sprintf("files of type `%s` can't be redacted, only text files are accepted", unredactable.ContentType), http.StatusUnsupportedMediaType)
+				return
+			} else if h.rejectBodyTooLarge(w, err) {
+				result = uploadResultRejected
 				return
 			} else {
 				oplog := httplog.LogEntry(r.Context())
@@ -119,28 +241,118 @@ func (h *logsHandler) post(w http.ResponseWriter, r *http.Request) {
 			writeInternalServerError(w)
 			return
 		}
+
+		totalSize += stagedLogFile.Size
+		stagedMetadata := logs.LogFileMetadata{
+			Name:        part.FileName(),
+			Redactions:  stagedLogFile.Redactions,
+			ContentType: stagedLogFile.ContentType,
+			Encoding:    stagedLogFile.Encoding,
+		}
+
+		if err := h.redisService.SetStagedLogFileMetadata(context.Background(), logFileId, stagedMetadata); err != nil {
+			oplog := httplog.LogEntry(r.Context())
+			oplog.Error("failed to store metadata of staged log file", slog.String("logFileId", logFileId.String()), utils.ErrAttr(err))
+			writeInternalServerError(w)
+			return
+		}
+	}
+
+	if fileCount == 0 {
+		result = uploadResultRejected
+		http.Error(w, "expected at least one file", http.StatusBadRequest)
+		return
+	}
+
+	if err := labels.Validate(); err != nil {
+		result = uploadResultRejected
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
 
 	logFileIds = logFileIds[:fileCount]
-	logBundleId, err := h.redisService.CreateLogBundle(context.Background(), logFileIds)
+	logBundle := logs.LogBundle{
+		LogFileIds:   logFileIds,
+		BundleLabels: labels,
+		Uploader:     uploader,
+		Size:         totalSize,
+	}
+
+	// the commit is reserved first, so the shutdown can't reject it once the bundle exists
+	pendingCommit, err := h.storageService.ReserveCommit(logFileIds)
 	if err != nil {
+		// the log files remain in staging like log files of failed commits
+		oplog := httplog.LogEntry(r.Context())
+		oplog.Error("failed to reserve commit of log files", utils.ErrAttr(err))
+		result = uploadResultRejected
+		writeShuttingDown(w)
+		return
+	}
+
+	logBundleId, err := h.redisService.CreateLogBundle(context.Background(), logBundle)
+	if err != nil {
+		pendingCommit.Cancel()
 		writeInternalServerError(w)
 		return
 	}
 
-	go func(storageService *storage.Service, logFileIds []logs.LogFileId) {
-		storageService.StoreLogFiles(logFileIds)
-	}(h.storageService, logFileIds)
+	logger := h.logger.With(slog.String("logBundleId", logBundleId.String()))
+	pendingCommit.StoreLogFilesInBackground(func(result storage.CommitResult) {
+		committed := result.Committed
+		if len(committed) != 0 {
+			h.indexService.IndexLogBundle(context.Background(), logBundleId, committed)
+		}
+
+		if uncommitted := getUncommittedLogFiles(logFileIds, committed); len(uncommitted) != 0 {
+			reasons := make(map[logs.LogFileId]string, len(uncommitted))
+			for _, logFileId := range uncommitted {
+				if inspectionErr, ok := result.Uninspected[logFileId]; ok {
+					reasons[logFileId] = "not inspected: " + inspectionErr
+					continue
+				}
+
+				reason, quarantined := result.Quarantined[logFileId]
+				if !quarantined {
+					reasons[logFileId] = ""
+					continue
+				}
+
+				reasons[logFileId] = "quarantined by " + reason
+				if err := h.redisService.SetLogFileQuarantine(context.Background(), logFileId, reason); err != nil {
+					logger.Error("failed to record quarantine of log file", slog.String("logFileId", logFileId.String()), utils.ErrAttr(err))
+				}
+			}
+
+			if err := h.redisService.RecordFailedCommits(context.Background(), logBundleId, reasons); err != nil {
+				logger.Error("failed to record failed commits", utils.ErrAttr(err))
+			}
+		}
+
+		if len(committed) != 0 && h.webhookService.Enabled() {
+			h.webhookService.Send(h.getWebhookPayload(context.Background(), logBundleId, logBundle, committed))
+		}
+	})
+
+	var output []byte
+	if strings.Contains(r.Header.Get("Accept"), "application/json") {
+		output, err = json.Marshal(types.UploadResponse{
+			BundleId: logBundleId,
+			FileIds:  logFileIds,
+		})
+
+		w.Header().Set("Content-Type", "application/json")
+	} else {
+		output, err = logBundleId.MarshalText()
+	}
 
-	idString, err := logBundleId.MarshalText()
 	if err != nil {
 		oplog := httplog.LogEntry(r.Context())
-		oplog.Error("unexpected error marshaling id to text", utils.ErrAttr(err))
+		oplog.Error("unexpected error marshaling response", utils.ErrAttr(err))
 		writeInternalServerError(w)
 		return
 	}
 
-	_, err = w.Write(idString)
+	_, err = w.Write(output)
 	if err != nil {
 		oplog := httplog.LogEntry(r.Context())
 		oplog.Error("unexpected error writing output", utils.ErrAttr(err))
@@ -148,12 +360,58 @@ func (h *logsHandler) post(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	result = uploadResultSuccess
+	h.metrics.uploadFiles.Observe(float64(fileCount))
 	w.WriteHeader(http.StatusOK)
 }
 
+// rejectBodyTooLarge writes the error response if a chunked body exceeded the content length
+// limit and returns false for all other errors.
+func (h *logsHandler) rejectBodyTooLarge(w http.ResponseWriter, err error) bool {
+	var maxBytesError *http.MaxBytesError
+	if !errors.As(err, &maxBytesError) {
+		return false
+	}
+
+	h.metrics.rejections.WithLabelValues(rejectionReasonContentLength).Inc()
+	http.Error(w, fmt.Sprintf("the body is over the limit of %d bytes", h.contentLengthLimit), http.StatusRequestEntityTooLarge)
+	return true
+}
+
+// getUploader returns the name of the uploader of the upload key. Uploads without a key are
+// anonymous unless keys are required. Unknown basic credentials are ignored as well because
+// proxies can add their own, but an unknown bearer token is a wrong upload key. Returns false
+// if the key is invalid, or if keys are required and the key is missing.
+func (h *logsHandler) getUploader(r *http.Request) (string, bool) {
+	token, ok := getAuthorizationToken(r)
+	if !ok {
+		return "", !h.requireUploadKey
+	}
+
+	index := findToken(h.uploadKeys, token)
+	if index == -1 {
+		_, _, isBasic := r.BasicAuth()
+		return "", isBasic && !h.requireUploadKey
+	}
+
+	return h.uploaderNames[index], true
+}
+
 func (h *logsHandler) getFile(w http.ResponseWriter, r *http.Request) {
 	logFileId := r.Context().Value("id").(logs.LogFileId)
 
+	encodingInput := r.URL.Query().Get(types.EncodingParam)
+	if encodingInput != "" && charset.Encoding(encodingInput) != charset.Utf8 {
+		http.Error(w, fmt.Sprintf("query parameter `%s` must be `%s`", types.EncodingParam, charset.Utf8), http.StatusBadRequest)
+		return
+	}
+
+	selection, hasSelection, err := parseLineSelection(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	// TODO: check with redis?
 
 	file, err := h.storageService.OpenLogFile(logFileId)
@@ -163,6 +421,7 @@ func (h *logsHandler) getFile(w http.ResponseWriter, r *http.Request) {
 
 	if err != nil {
 		if os.IsNotExist(err) {
+			h.metrics.fileRequests.WithLabelValues(fileResultMiss).Inc()
 			http.NotFound(w, r)
 			return
 		}
@@ -171,31 +430,230 @@ func (h *logsHandler) getFile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	w.Header().Set("Content-Type", "text/plain")
+	h.metrics.fileRequests.WithLabelValues(fileResultHit).Inc()
 	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+
+	if hasSelection {
+		h.writeLines(w, r, logFileId, selection)
+		return
+	}
+
+	metadata, err := h.redisService.GetLogFileMetadata(r.Context(), logFileId)
+	if err != nil && !errors.Is(err, redis.ErrNotFound) {
+		// the log file is still served, assuming it's text
+		oplog := httplog.LogEntry(r.Context())
+		oplog.Warn("failed to get metadata of log file", slog.String("logFileId", logFileId.String()), utils.ErrAttr(err))
+	}
+
+	if !metadata.IsText() {
+		// binaries are downloaded instead of being rendered as text by the browser
+		w.Header().Set("Content-Type", metadata.ContentType)
+		w.Header().Set("Content-Disposition", getAttachmentDisposition(logFileId, metadata.Name))
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		http.ServeContent(w, r, logFileId.String(), time.UnixMilli(0), file)
+		return
+	}
+
+	// the original bytes are served unless they are decoded explicitly
+	var reader io.Reader = file
+	encoding := metadata.Encoding
+	decode := encodingInput != "" && !encoding.IsUtf8()
+	if decode {
+		reader = charset.NewReader(file, encoding)
+		encoding = charset.Utf8
+	}
+
+	if encoding == charset.Unknown {
+		w.Header().Set("Content-Type", "text/plain")
+	} else {
+		w.Header().Set("Content-Type", "text/plain; charset="+string(encoding))
+	}
+
+	if r.URL.Query().Get(types.StripAnsiParam) == "1" {
+		// the size of the stripped file is unknown, so ranges aren't supported
+		w.WriteHeader(http.StatusOK)
+		if _, err := io.Copy(ansi.NewStripWriter(w), reader); err != nil {
+			oplog := httplog.LogEntry(r.Context())
+			oplog.Error("failed to write log file without escape sequences", slog.String("logFileId", logFileId.String()), utils.ErrAttr(err))
+		}
+
+		return
+	}
+
+	if decode {
+		// the size of the decoded file is unknown as well
+		w.WriteHeader(http.StatusOK)
+		if _, err := io.Copy(w, reader); err != nil {
+			oplog := httplog.LogEntry(r.Context())
+			oplog.Error("failed to write decoded log file", slog.String("logFileId", logFileId.String()), utils.ErrAttr(err))
+		}
+
+		return
+	}
+
 	http.ServeContent(w, r, logFileId.String(), time.UnixMilli(0), file)
 }
 
-func (h *logsHandler) getBundle(w http.ResponseWriter, r *http.Request) {
+// lineSelection contains the lines of a log file requested with the `lines` or `tail` query
+// parameter. Line numbers are 1-based and inclusive.
+type lineSelection struct {
+	first uint64
+	last  uint64
+	tail  uint64
+}
+
+// parseLineSelection parses the `lines` and `tail` query parameters. It returns false if neither
+// of them is set.
+func parseLineSelection(query url.Values) (lineSelection, bool, error) {
+	linesInput := query.Get(types.LinesParam)
+	tailInput := query.Get(types.TailParam)
+
+	switch {
+	case linesInput != "" && tailInput != "":
+		return lineSelection{}, false, fmt.Errorf("query parameters `%s` and `%s` can't be combined", types.LinesParam, types.TailParam)
+	case linesInput != "":
+		firstInput, lastInput, _ := strings.Cut(linesInput, "-")
+		first, firstErr := strconv.ParseUint(firstInput, 10, 64)
+		last, lastErr := strconv.ParseUint(lastInput, 10, 64)
+		if firstErr != nil || lastErr != nil || first == 0 || last < first {
+			return lineSelection{}, false, fmt.Errorf("query parameter `%s` must be a range of line numbers like `1000-2000`", types.LinesParam)
+		}
+
+		return lineSelection{first: first, last: last}, true, nil
+	case tailInput != "":
+		tail, err := strconv.ParseUint(tailInput, 10, 64)
+		if err != nil || tail == 0 {
+			return lineSelection{}, false, fmt.Errorf("query parameter `%s` must be a positive number", types.TailParam)
+		}
+
+		return lineSelection{tail: tail}, true, nil
+	default:
+		return lineSelection{}, false, nil
+	}
+}
+
+// resolve returns the lines of a log file with lineCount lines. The last line is lower than the
+// first one if no lines are selected. It returns false if the range starts after the last line.
+func (s lineSelection) resolve(lineCount uint64) (uint64, uint64, bool) {
+	if s.tail != 0 {
+		return lineCount - min(s.tail, lineCount) + 1, lineCount, true
+	}
+
+	return s.first, min(s.last, lineCount), s.first <= lineCount
+}
+
+// writeLines writes the selected lines of a text file. The line offset index is used to start
+// reading close to the first line, instead of scanning the log file from the start.
+func (h *logsHandler) writeLines(w http.ResponseWriter, r *http.Request, logFileId logs.LogFileId, selection lineSelection) {
+	oplog := httplog.LogEntry(r.Context())
+
+	metadata, err := h.indexService.GetLogFileMetadata(r.Context(), logFileId)
+	if err != nil {
+		oplog.Error("failed to get metadata of log file", slog.String("logFileId", logFileId.String()), utils.ErrAttr(err))
+		writeInternalServerError(w)
+		return
+	}
+
+	if !metadata.IsText() {
+		http.Error(w, "lines can only be selected in text files", http.StatusBadRequest)
+		return
+	}
+
+	first, last, ok := selection.resolve(metadata.LineCount)
+	if !ok {
+		http.Error(w, fmt.Sprintf("the log file only has `%d` lines", metadata.LineCount), http.StatusRequestedRangeNotSatisfiable)
+		return
+	}
+
+	if metadata.Encoding == charset.Unknown {
+		w.Header().Set("Content-Type", "text/plain")
+	} else {
+		w.Header().Set("Content-Type", "text/plain; charset="+string(charset.Utf8))
+	}
+
+	if last < first {
+		w.Header().Set(types.LineRangeHeader, fmt.Sprintf("*/%d", metadata.LineCount))
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	offset, skip, err := h.indexService.GetLineOffset(r.Context(), logFileId, first)
+	if err != nil {
+		oplog.Error("failed to get line offset of log file", slog.String("logFileId", logFileId.String()), utils.ErrAttr(err))
+		writeInternalServerError(w)
+		return
+	}
+
+	var writer io.Writer = w
+	if r.URL.Query().Get(types.StripAnsiParam) == "1" {
+		writer = ansi.NewStripWriter(w)
+	}
+
+	w.Header().Set(types.LineRangeHeader, fmt.Sprintf("%d-%d/%d", first, last, metadata.LineCount))
+	w.WriteHeader(http.StatusOK)
+	if err := h.storageService.WriteLogFileLines(writer, logFileId, metadata.Encoding, offset, skip, last-first+1); err != nil {
+		oplog.Error("failed to write lines of log file", slog.String("logFileId", logFileId.String()), utils.ErrAttr(err))
+	}
+}
+
+// getAttachmentDisposition returns a Content-Disposition header that downloads the log file with
+// its original name, or its ID if the name is unknown.
+func getAttachmentDisposition(logFileId logs.LogFileId, name string) string {
+	if name == "" {
+		name = logFileId.String()
+	}
+
+	disposition := mime.FormatMediaType("attachment", map[string]string{"filename": name})
+	if disposition == "" {
+		// the name can't be encoded, like when it contains control characters
+		return mime.FormatMediaType("attachment", map[string]string{"filename": logFileId.String()})
+	}
+
+	return disposition
+}
+
+// getLogBundle returns the record of the log bundle in the request context. It writes an
+// error response and returns false if the log bundle can't be retrieved.
+func (h *logsHandler) getLogBundle(w http.ResponseWriter, r *http.Request) (logs.LogBundleId, logs.LogBundle, bool) {
 	logBundleId := r.Context().Value("id").(logs.LogBundleId)
 
-	logFileIds, err := h.redisService.GetLogBundle(r.Context(), logBundleId)
+	logBundle, err := h.redisService.GetLogBundleRecord(r.Context(), logBundleId)
 	if err != nil {
 		if errors.Is(err, redis.ErrNotFound) {
 			http.NotFound(w, r)
-			return
+			return logBundleId, logBundle, false
 		}
 
 		oplog := httplog.LogEntry(r.Context())
 		oplog.Error("unexpected error while getting log bundle from redis", slog.String("logBundleId", logBundleId.String()), utils.ErrAttr(err))
 		writeInternalServerError(w)
+		return logBundleId, logBundle, false
+	}
+
+	return logBundleId, logBundle, true
+}
+
+func (h *logsHandler) getBundle(w http.ResponseWriter, r *http.Request) {
+	logBundleId, logBundle, ok := h.getLogBundle(w, r)
+	if !ok {
 		return
 	}
 
-	jsonBytes, err := json.Marshal(logFileIds)
+	var res any = types.BundleResponse(logBundle.LogFileIds)
+	if r.URL.Query().Get(types.BundleDetailsParam) == "1" {
+		res = types.BundleDetailsResponse{
+			FileIds:     logBundle.LogFileIds,
+			Title:       logBundle.Title,
+			Description: logBundle.Description,
+			Tags:        logBundle.Tags,
+		}
+	}
+
+	jsonBytes, err := json.Marshal(res)
+
 	if err != nil {
 		oplog := httplog.LogEntry(r.Context())
-		oplog.Error("unexpected error while marshaling log file IDs of log bundle", slog.String("logBundleId", logBundleId.String()), utils.ErrAttr(err))
+		oplog.Error("unexpected error while marshaling log bundle", slog.String("logBundleId", logBundleId.String()), utils.ErrAttr(err))
 		writeInternalServerError(w)
 		return
 	}
@@ -204,3 +662,207 @@ func (h *logsHandler) getBundle(w http.ResponseWriter, r *http.Request) {
 	_, _ = w.Write(jsonBytes)
 	w.WriteHeader(http.StatusOK)
 }
+
+func (h *logsHandler) getArchive(w http.ResponseWriter, r *http.Request) {
+	logBundleId, logBundle, ok := h.getLogBundle(w, r)
+	if !ok {
+		return
+	}
+
+	logFileIds := logBundle.LogFileIds
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s.zip\"", logBundleId.String()))
+
+	oplog := httplog.LogEntry(r.Context())
+	zipWriter := zip.NewWriter(w)
+
+	names := make([]string, len(logFileIds))
+	for i, logFileId := range logFileIds {
+		metadata, err := h.redisService.GetLogFileMetadata(r.Context(), logFileId)
+		if err != nil && !errors.Is(err, redis.ErrNotFound) {
+			// the entry is named after the ID instead
+			oplog.Warn("failed to get metadata of log file", slog.String("logFileId", logFileId.String()), utils.ErrAttr(err))
+		}
+
+		names[i] = metadata.Name
+	}
+
+	for i, entryName := range getArchiveEntryNames(logFileIds, names) {
+		logFileId := logFileIds[i]
+		if err := h.writeArchiveEntry(zipWriter, logFileId, entryName); err != nil {
+			// NOTE: the response has already been started, the client will receive a broken archive
+			oplog.Error("failed to write log file to archive", slog.String("logBundleId", logBundleId.String()), slog.String("logFileId", logFileId.String()), utils.ErrAttr(err))
+			return
+		}
+	}
+
+	if err := zipWriter.Close(); err != nil {
+		oplog.Error("failed to finish archive", slog.String("logBundleId", logBundleId.String()), utils.ErrAttr(err))
+	}
+}
+
+// getArchiveEntryNames returns the original names of the log files as names of the archive
+// entries. Only the last element of a name is kept and names that are taken are suffixed with
+// the ID, log files without a name are named after their ID.
+func getArchiveEntryNames(logFileIds []logs.LogFileId, names []string) []string {
+	res := make([]string, len(logFileIds))
+	taken := make(map[string]struct{}, len(logFileIds))
+
+	for i, logFileId := range logFileIds {
+		name := path.Base(strings.ReplaceAll(names[i], `\`, "/"))
+		if names[i] == "" || name == "." || name == ".." || name == "/" {
+			name = logFileId.String()
+		}
+
+		if _, ok := taken[name]; ok {
+			extension := path.Ext(name)
+			name = strings.TrimSuffix(name, extension) + "-" + logFileId.String() + extension
+		}
+
+		taken[name] = struct{}{}
+		res[i] = name
+	}
+
+	return res
+}
+
+func (h *logsHandler) writeArchiveEntry(zipWriter *zip.Writer, logFileId logs.LogFileId, entryName string) error {
+	file, err := h.storageService.OpenLogFile(logFileId)
+	if err != nil {
+		return err
+	}
+
+	defer func(file *os.File) {
+		_ = file.Close()
+	}(file)
+
+	fileInfo, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	header, err := zip.FileInfoHeader(fileInfo)
+	if err != nil {
+		return err
+	}
+
+	header.Name = entryName
+	header.Method = zip.Deflate
+
+	entryWriter, err := zipWriter.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(entryWriter, file)
+	return err
+}
+
+const defaultSearchLimit = 100
+const maxSearchLimit = 1000
+
+func (h *logsHandler) search(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	input := query.Get(types.SearchQueryParam)
+	if input == "" {
+		http.Error(w, fmt.Sprintf("query parameter `%s` is required", types.SearchQueryParam), http.StatusBadRequest)
+		return
+	}
+
+	limit := defaultSearchLimit
+	if limitInput := query.Get(types.SearchLimitParam); limitInput != "" {
+		parsed, err := strconv.Atoi(limitInput)
+		if err != nil || parsed < 1 || parsed > maxSearchLimit {
+			http.Error(w, fmt.Sprintf("query parameter `%s` must be between 1 and %d", types.SearchLimitParam, maxSearchLimit), http.StatusBadRequest)
+			return
+		}
+
+		limit = parsed
+	}
+
+	expression := input
+	if query.Get(types.SearchRegexParam) != "1" {
+		expression = regexp.QuoteMeta(input)
+	}
+
+	if query.Get(types.SearchCaseInsensitiveParam) == "1" {
+		expression = "(?i)" + expression
+	}
+
+	pattern, err := regexp.Compile(expression)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid regular expression: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	logBundleId, logBundle, ok := h.getLogBundle(w, r)
+	if !ok {
+		return
+	}
+
+	logFileIds := logBundle.LogFileIds
+
+	res := types.SearchResponse{
+		Matches: []types.SearchMatch{},
+	}
+
+	for _, logFileId := range logFileIds {
+		encoding, err := h.indexService.GetLogFileEncoding(r.Context(), logFileId)
+		if err != nil {
+			oplog := httplog.LogEntry(r.Context())
+			oplog.Error("unexpected error while getting encoding of log file", slog.String("logFileId", logFileId.String()), utils.ErrAttr(err))
+			writeInternalServerError(w)
+			return
+		}
+
+		matches, truncated, err := h.storageService.SearchLogFile(logFileId, encoding, pattern, limit-len(res.Matches))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+
+			oplog := httplog.LogEntry(r.Context())
+			oplog.Error("unexpected error while searching log file", slog.String("logBundleId", logBundleId.String()), slog.String("logFileId", logFileId.String()), utils.ErrAttr(err))
+			writeInternalServerError(w)
+			return
+		}
+
+		for _, match := range matches {
+			res.Matches = append(res.Matches, types.SearchMatch{
+				FileId: match.LogFileId,
+				Line:   match.Line,
+				Text:   match.Text,
+			})
+		}
+
+		if truncated {
+			res.Truncated = true
+			break
+		}
+	}
+
+	jsonBytes, err := json.Marshal(res)
+	if err != nil {
+		oplog := httplog.LogEntry(r.Context())
+		oplog.Error("unexpected error while marshaling search results", slog.String("logBundleId", logBundleId.String()), utils.ErrAttr(err))
+		writeInternalServerError(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(jsonBytes)
+}
+
+// getUncommittedLogFiles returns the log files that are missing from the committed log files.
+func getUncommittedLogFiles(logFileIds []logs.LogFileId, committed []logs.LogFileId) []logs.LogFileId {
+	var res []logs.LogFileId
+	for _, logFileId := range logFileIds {
+		if !slices.Contains(committed, logFileId) {
+			res = append(res, logFileId)
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,148 @@
+package api
+
+import (
+	"encoding/base64"
+	"github.com/oklog/ulid/v2"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"simple-log-store/internal/logs"
+	"testing"
+)
+
+func TestParseLineSelection(t *testing.T) {
+	tests := []struct {
+		query    string
+		expected lineSelection
+		selected bool
+		invalid  bool
+	}{
+		{query: "", selected: false},
+		{query: "lines=1000-2000", expected: lineSelection{first: 1000, last: 2000}, selected: true},
+		{query: "lines=5-5", expected: lineSelection{first: 5, last: 5}, selected: true},
+		{query: "tail=500", expected: lineSelection{tail: 500}, selected: true},
+		{query: "lines=0-10", invalid: true},
+		{query: "lines=10-5", invalid: true},
+		{query: "lines=10", invalid: true},
+		{query: "lines=a-b", invalid: true},
+		{query: "lines=-5", invalid: true},
+		{query: "tail=0", invalid: true},
+		{query: "tail=-1", invalid: true},
+		{query: "lines=1-2&tail=5", invalid: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			query, err := url.ParseQuery(test.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			selection, selected, err := parseLineSelection(query)
+			if test.invalid {
+				if err == nil {
+					t.Errorf("expected an error, got %+v", selection)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if selected != test.selected || selection != test.expected {
+				t.Errorf("expected %+v (%t), got %+v (%t)", test.expected, test.selected, selection, selected)
+			}
+		})
+	}
+}
+
+func TestLineSelectionResolve(t *testing.T) {
+	tests := []struct {
+		name      string
+		selection lineSelection
+		lineCount uint64
+		first     uint64
+		last      uint64
+		ok        bool
+	}{
+		{name: "range", selection: lineSelection{first: 10, last: 20}, lineCount: 100, first: 10, last: 20, ok: true},
+		{name: "range past the end", selection: lineSelection{first: 90, last: 200}, lineCount: 100, first: 90, last: 100, ok: true},
+		{name: "range after the end", selection: lineSelection{first: 101, last: 200}, lineCount: 100, ok: false},
+		{name: "tail", selection: lineSelection{tail: 10}, lineCount: 100, first: 91, last: 100, ok: true},
+		{name: "tail longer than the file", selection: lineSelection{tail: 500}, lineCount: 100, first: 1, last: 100, ok: true},
+		{name: "tail of an empty file", selection: lineSelection{tail: 10}, lineCount: 0, first: 1, last: 0, ok: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			first, last, ok := test.selection.resolve(test.lineCount)
+			if ok != test.ok {
+				t.Fatalf("expected %t, got %t", test.ok, ok)
+			}
+
+			if ok && (first != test.first || last != test.last) {
+				t.Errorf("expected %d-%d, got %d-%d", test.first, test.last, first, last)
+			}
+		})
+	}
+}
+
+func TestGetArchiveEntryNames(t *testing.T) {
+	logFileIds := []logs.LogFileId{ulid.Make(), ulid.Make(), ulid.Make(), ulid.Make(), ulid.Make()}
+	names := []string{"app.log", "", "app.log", `C:\logs\crash.txt`, ".."}
+
+	expected := []string{
+		"app.log",
+		logFileIds[1].String(),
+		"app-" + logFileIds[2].String() + ".log",
+		"crash.txt",
+		logFileIds[4].String(),
+	}
+
+	actual := getArchiveEntryNames(logFileIds, names)
+	for i := range expected {
+		if actual[i] != expected[i] {
+			t.Errorf("expected `%s`, got `%s`", expected[i], actual[i])
+		}
+	}
+}
+
+func TestGetUploader(t *testing.T) {
+	tests := []struct {
+		name             string
+		authorization    string
+		requireUploadKey bool
+		expected         string
+		ok               bool
+	}{
+		{name: "anonymous", ok: true},
+		{name: "anonymous required", requireUploadKey: true, ok: false},
+		{name: "bearer", authorization: "Bearer secret", expected: "ci", ok: true},
+		{name: "basic", authorization: "Basic " + base64.StdEncoding.EncodeToString([]byte("user:secret")), expected: "ci", ok: true},
+		{name: "unknown bearer", authorization: "Bearer wrong", ok: false},
+		{name: "unknown basic", authorization: "Basic " + base64.StdEncoding.EncodeToString([]byte("proxy:wrong")), ok: true},
+		{name: "unknown basic required", authorization: "Basic " + base64.StdEncoding.EncodeToString([]byte("proxy:wrong")), requireUploadKey: true, ok: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			h := &logsHandler{
+				uploadKeys:       []string{"secret"},
+				uploaderNames:    []string{"ci"},
+				requireUploadKey: test.requireUploadKey,
+			}
+
+			r := httptest.NewRequest(http.MethodPost, "/logs", nil)
+			if test.authorization != "" {
+				r.Header.Set("Authorization", test.authorization)
+			}
+
+			uploader, ok := h.getUploader(r)
+			if uploader != test.expected || ok != test.ok {
+				t.Errorf("expected `%s` (%t), got `%s` (%t)", test.expected, test.ok, uploader, ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"simple-log-store/internal/metrics"
+)
+
+const (
+	uploadResultSuccess  = "success"
+	uploadResultRejected = "rejected"
+	uploadResultError    = "error"
+)
+
+const (
+	rejectionReasonContentLength = "content_length"
+	rejectionReasonFileCount     = "file_count"
+	rejectionReasonFileSize      = "file_size"
+)
+
+const (
+	fileResultHit  = "hit"
+	fileResultMiss = "miss"
+)
+
+type apiMetrics struct {
+	uploads        *prometheus.CounterVec
+	uploadDuration prometheus.Histogram
+	uploadFiles    prometheus.Histogram
+	rejections     *prometheus.CounterVec
+	fileRequests   *prometheus.CounterVec
+}
+
+func newApiMetrics(registerer prometheus.Registerer) *apiMetrics {
+	factory := promauto.With(registerer)
+
+	return &apiMetrics{
+		uploads: factory.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: "api",
+			Name:      "uploads_total",
+			Help:      "Number of upload requests by result.",
+		}, []string{"result"}),
+		uploadDuration: factory.NewHistogram(prometheus.HistogramOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: "api",
+			Name:      "upload_duration_seconds",
+			Help:      "Time it took to handle an upload request.",
+			Buckets:   prometheus.DefBuckets,
+		}),
+		uploadFiles: factory.NewHistogram(prometheus.HistogramOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: "api",
+			Name:      "upload_files",
+			Help:      "Number of files in a successfully uploaded bundle.",
+			Buckets:   prometheus.LinearBuckets(1, 1, 10),
+		}),
+		rejections: factory.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: "api",
+			Name:      "upload_rejections_total",
+			Help:      "Number of uploads rejected with 413 Request Entity Too Large by reason.",
+		}, []string{"reason"}),
+		fileRequests: factory.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: "api",
+			Name:      "file_requests_total",
+			Help:      "Number of log file requests by whether the file was found.",
+		}, []string{"result"}),
+	}
+}
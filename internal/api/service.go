@@ -1,26 +1,43 @@
 package api
 
 import (
+	"context"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/httplog/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"io"
 	"log/slog"
 	"net/http"
+	"simple-log-store/internal/assets"
 	"simple-log-store/internal/config"
+	"simple-log-store/internal/index"
 	"simple-log-store/internal/redis"
 	"simple-log-store/internal/storage"
+	"simple-log-store/internal/webhook"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
 type Service struct {
 	Handler http.Handler
+
+	inFlightUploads *atomic.Int64
+
+	// requests that are handled, the shutdown waits for them before redis is closed
+	requestsMutex    sync.Mutex
+	inFlightRequests sync.WaitGroup
+	// set once the shutdown waits for the requests, new requests are rejected afterward
+	closed bool
 }
 
-func CreateService(appConfig *config.AppConfig, storageService *storage.Service, redisService *redis.Service, logWriter io.Writer) *Service {
+func CreateService(appConfig *config.AppConfig, storageService *storage.Service, redisService *redis.Service, indexService *index.Service, webhookService *webhook.Service, registry *prometheus.Registry, logWriter io.Writer) *Service {
 	r := chi.NewRouter()
 	service := &Service{
-		Handler: r,
+		Handler:         r,
+		inFlightUploads: &atomic.Int64{},
 	}
 
 	// https://github.com/go-chi/httplog/blob/master/options.go
@@ -34,6 +51,7 @@ func CreateService(appConfig *config.AppConfig, storageService *storage.Service,
 		Writer:           logWriter,
 	})
 
+	r.Use(service.trackRequests)
 	r.Use(middleware.RequestID)
 	// included in RequestLogger: r.Use(middleware.RealIP)
 	r.Use(httplog.RequestLogger(requestLogger))
@@ -41,12 +59,62 @@ func CreateService(appConfig *config.AppConfig, storageService *storage.Service,
 
 	r.Use(middleware.Heartbeat("/ping"))
 
+	r.Handle(assets.PathPrefix+"*", assets.Handler())
+	r.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{Registry: registry}))
+
 	r.Get("/", func(w http.ResponseWriter, r *http.Request) {
 		http.NotFound(w, r)
 	})
 
-	registerLogsHandler(r, appConfig, storageService, redisService)
-	registerFrontendHandler(r, storageService, redisService)
+	registerHealthHandler(r, appConfig, storageService, redisService)
+	registerLogsHandler(r, requestLogger.Logger, appConfig, storageService, redisService, indexService, webhookService, newApiMetrics(registry), service.inFlightUploads)
+	registerFrontendHandler(r, appConfig, storageService, redisService, indexService)
+	registerAdminHandler(r, appConfig, storageService, redisService)
 
 	return service
 }
+
+// InFlightUploads returns the number of uploads that are currently being processed.
+func (s *Service) InFlightUploads() int64 {
+	return s.inFlightUploads.Load()
+}
+
+// trackRequests counts the running handlers, which can outlive their connections when the
+// shutdown abandons them.
+func (s *Service) trackRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.requestsMutex.Lock()
+		if s.closed {
+			s.requestsMutex.Unlock()
+			writeShuttingDown(w)
+			return
+		}
+
+		s.inFlightRequests.Add(1)
+		s.requestsMutex.Unlock()
+
+		defer s.inFlightRequests.Done()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// WaitForRequests stops accepting new requests and blocks until all running handlers
+// returned or the context is done.
+func (s *Service) WaitForRequests(ctx context.Context) error {
+	s.requestsMutex.Lock()
+	s.closed = true
+	s.requestsMutex.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.inFlightRequests.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
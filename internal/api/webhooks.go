@@ -0,0 +1,154 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/go-chi/httplog/v2"
+	"github.com/oklog/ulid/v2"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"simple-log-store/internal/logs"
+	"simple-log-store/internal/redis"
+	"simple-log-store/internal/utils"
+	"simple-log-store/pkg/types"
+	"strconv"
+)
+
+// getWebhookPayload describes the committed log files of the new log bundle. Log files
+// without metadata are still included, only with their name and size.
+func (h *logsHandler) getWebhookPayload(ctx context.Context, logBundleId logs.LogBundleId, logBundle logs.LogBundle, committed []logs.LogFileId) types.WebhookPayload {
+	payload := types.WebhookPayload{
+		Event:         types.WebhookEventBundleCommitted,
+		BundleId:      logBundleId,
+		CreatedAt:     ulid.Time(logBundleId.Time()).UTC(),
+		Title:         logBundle.Title,
+		Description:   logBundle.Description,
+		Tags:          logBundle.Tags,
+		Uploader:      logBundle.Uploader,
+		Files:         make([]types.WebhookFile, len(committed)),
+		FailedFileIds: getUncommittedLogFiles(logBundle.LogFileIds, committed),
+	}
+
+	if h.publicUrl != "" {
+		payload.ViewUrl = h.publicUrl + fmt.Sprintf(types.ViewPath, logBundleId.String())
+	}
+
+	for i, logFileId := range committed {
+		file := &payload.Files[i]
+		file.FileId = logFileId
+
+		if fileInfo, err := h.storageService.StatLogFile(logFileId); err == nil {
+			file.Size = uint64(fileInfo.Size)
+		}
+
+		metadata, err := h.indexService.GetLogFileMetadata(ctx, logFileId)
+		if err != nil {
+			continue
+		}
+
+		file.Name = metadata.Name
+		file.LineCount = metadata.LineCount
+		file.Format = string(metadata.Format)
+		file.Redactions = metadata.Redactions
+
+		if len(metadata.LevelCounts) != 0 {
+			file.LevelCounts = make(map[string]uint64, len(metadata.LevelCounts))
+			for level, count := range metadata.LevelCounts {
+				file.LevelCounts[level.String()] = count
+			}
+		}
+	}
+
+	return payload
+}
+
+// parseDeliveryQuery parses the query parameters of `GET /logs/webhooks/deliveries`.
+func parseDeliveryQuery(query url.Values) (redis.WebhookDeliveryQuery, error) {
+	res := redis.WebhookDeliveryQuery{
+		Status: query.Get(types.DeliveryStatusParam),
+		Limit:  defaultListLimit,
+	}
+
+	switch res.Status {
+	case "", types.DeliveryStatusPending, types.DeliveryStatusSucceeded, types.DeliveryStatusFailed:
+	default:
+		return res, fmt.Errorf("invalid `%s`, expected `%s`, `%s` or `%s`", types.DeliveryStatusParam, types.DeliveryStatusPending, types.DeliveryStatusSucceeded, types.DeliveryStatusFailed)
+	}
+
+	var err error
+	if bundle := query.Get(types.DeliveryBundleParam); bundle != "" {
+		if res.LogBundleId, err = logs.ParseId(bundle); err != nil {
+			return res, fmt.Errorf("invalid `%s`", types.DeliveryBundleParam)
+		}
+	}
+
+	if cursor := query.Get(types.DeliveryCursorParam); cursor != "" {
+		if res.Cursor, err = ulid.Parse(cursor); err != nil {
+			return res, fmt.Errorf("invalid `%s`", types.DeliveryCursorParam)
+		}
+	}
+
+	if limit := query.Get(types.DeliveryLimitParam); limit != "" {
+		parsed, err := strconv.Atoi(limit)
+		if err != nil || parsed < 1 || parsed > maxListLimit {
+			return res, fmt.Errorf("invalid `%s`, expected a number between 1 and %d", types.DeliveryLimitParam, maxListLimit)
+		}
+
+		res.Limit = parsed
+	}
+
+	return res, nil
+}
+
+func (h *logsHandler) listDeliveries(w http.ResponseWriter, r *http.Request) {
+	query, err := parseDeliveryQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	page, err := h.redisService.QueryWebhookDeliveries(r.Context(), query)
+	if err != nil {
+		oplog := httplog.LogEntry(r.Context())
+		oplog.Error("failed to query webhook deliveries", utils.ErrAttr(err))
+		writeInternalServerError(w)
+		return
+	}
+
+	res := types.DeliveryListResponse{
+		Deliveries: make([]types.Delivery, len(page.Deliveries)),
+	}
+
+	for i, delivery := range page.Deliveries {
+		res.Deliveries[i] = types.Delivery{
+			DeliveryId: delivery.DeliveryId,
+			BundleId:   delivery.LogBundleId,
+			Event:      delivery.Event,
+			Url:        delivery.Url,
+			Status:     delivery.Status,
+			Attempts:   delivery.Attempts,
+			StatusCode: delivery.StatusCode,
+			Error:      delivery.Error,
+			UpdatedAt:  delivery.UpdatedAt,
+		}
+	}
+
+	var zeroId ulid.ULID
+	if page.NextCursor != zeroId {
+		res.NextCursor = page.NextCursor.String()
+	}
+
+	jsonBytes, err := json.Marshal(res)
+	if err != nil {
+		oplog := httplog.LogEntry(r.Context())
+		oplog.Error("unexpected error while marshaling webhook deliveries", slog.Int("count", len(res.Deliveries)), utils.ErrAttr(err))
+		writeInternalServerError(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	_, _ = w.Write(jsonBytes)
+}
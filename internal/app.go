@@ -4,25 +4,33 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/sethvargo/go-envconfig"
 	"io"
 	"log/slog"
 	"net/http"
 	"simple-log-store/internal/api"
 	"simple-log-store/internal/config"
+	"simple-log-store/internal/index"
+	"simple-log-store/internal/metrics"
 	"simple-log-store/internal/redis"
 	"simple-log-store/internal/storage"
 	"simple-log-store/internal/utils"
+	"simple-log-store/internal/webhook"
+	"sync/atomic"
 	"time"
 )
 
 type App struct {
 	Logger *slog.Logger
 
-	Config         *config.AppConfig
-	StorageService *storage.Service
-	RedisService   *redis.Service
-	ApiService     *api.Service
+	Config          *config.AppConfig
+	MetricsRegistry *prometheus.Registry
+	StorageService  *storage.Service
+	RedisService    *redis.Service
+	IndexService    *index.Service
+	WebhookService  *webhook.Service
+	ApiService      *api.Service
 }
 
 func New(logger *slog.Logger, logWriter io.Writer) (*App, error) {
@@ -32,24 +40,36 @@ func New(logger *slog.Logger, logWriter io.Writer) (*App, error) {
 		return nil, fmt.Errorf("failed to parse environment variables: %w", err)
 	}
 
-	storageService, err := storage.CreateService(&appConfig, logger)
+	metricsRegistry := metrics.NewRegistry()
+
+	storageService, err := storage.CreateService(&appConfig, logger, metricsRegistry)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create storage service: %w", err)
 	}
 
-	redisService, err := redis.CreateService(&appConfig, logger)
+	redisService, err := redis.CreateService(&appConfig, logger, metricsRegistry)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create redis service: %w", err)
 	}
 
-	apiService := api.CreateService(&appConfig, storageService, redisService, logWriter)
+	indexService := index.CreateService(&appConfig, logger, storageService, redisService)
+
+	webhookService, err := webhook.CreateService(&appConfig, logger, redisService, metricsRegistry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create webhook service: %w", err)
+	}
+
+	apiService := api.CreateService(&appConfig, storageService, redisService, indexService, webhookService, metricsRegistry, logWriter)
 
 	app := &App{
-		Logger:         logger,
-		Config:         &appConfig,
-		StorageService: storageService,
-		RedisService:   redisService,
-		ApiService:     apiService,
+		Logger:          logger,
+		Config:          &appConfig,
+		MetricsRegistry: metricsRegistry,
+		StorageService:  storageService,
+		RedisService:    redisService,
+		IndexService:    indexService,
+		WebhookService:  webhookService,
+		ApiService:      apiService,
 	}
 
 	return app, nil
@@ -62,31 +82,38 @@ func (app *App) Start(ctx context.Context) error {
 		Handler: app.ApiService.Handler,
 	}
 
-	if err := app.RedisService.Ping(); err != nil {
+	if err := app.RedisService.Ping(ctx); err != nil {
 		return err
 	}
 
-	defer func(redisService *redis.Service) {
-		redisService.Close()
-	}(app.RedisService)
-
 	app.Logger.Info("starting server", slog.Uint64("port", uint64(port)))
 
+	serverErr := make(chan error, 1)
 	go func(server *http.Server, logger *slog.Logger) {
 		err := server.ListenAndServe()
 		if err != nil {
 			if errors.Is(err, http.ErrServerClosed) {
 				logger.Info("server closed")
-			} else {
-				slog.Error("server shutdown unexpectedly", utils.ErrAttr(err))
+				return
 			}
+
+			logger.Error("server shutdown unexpectedly", utils.ErrAttr(err))
+			serverErr <- err
 		}
 	}(server, app.Logger)
 
 	cleanupInterval := app.Config.CleanupInterval
 	app.Logger.Info("starting cleanup goroutine", slog.Duration("cleanupInterval", cleanupInterval))
 
-	go func(ctx context.Context, storageService *storage.Service, interval time.Duration, retentionDuration time.Duration) {
+	// NOTE: the cleanup uses its own context because it must only be stopped after all pending commits are done
+	cleanupCtx, stopCleanup := context.WithCancel(context.Background())
+	defer stopCleanup()
+
+	cleanupDone := make(chan struct{})
+	cleanupRunning := &atomic.Bool{}
+	go func(ctx context.Context, interval time.Duration) {
+		defer close(cleanupDone)
+
 		ticker := time.NewTicker(interval)
 		defer ticker.Stop()
 
@@ -95,19 +122,137 @@ func (app *App) Start(ctx context.Context) error {
 			case <-ctx.Done():
 				return
 			case <-ticker.C:
-				before := time.Now().Add(-retentionDuration)
-				_ = storageService.RemoveOldLogFiles(before)
+				if ctx.Err() != nil {
+					return
+				}
+
+				cleanupRunning.Store(true)
+				if _, err := app.RemoveOldLogFiles(ctx); err != nil {
+					app.Logger.Error("failed to remove old log files", utils.ErrAttr(err))
+				}
+
+				if _, err := app.StorageService.RemoveOldQuarantinedFiles(time.Now().Add(-app.Config.LogRetentionDuration)); err != nil {
+					app.Logger.Error("failed to remove old quarantined log files", utils.ErrAttr(err))
+				}
+
+				if _, err := app.RedisService.PruneBundleIndexes(ctx); err != nil {
+					app.Logger.Error("failed to prune bundle indexes", utils.ErrAttr(err))
+				}
+
+				if _, err := app.RedisService.PruneSignatures(ctx); err != nil {
+					app.Logger.Error("failed to prune signatures", utils.ErrAttr(err))
+				}
+
+				if err := app.RecordStorageUsage(ctx); err != nil {
+					app.Logger.Error("failed to record storage usage", utils.ErrAttr(err))
+				}
+				cleanupRunning.Store(false)
 			}
 		}
-	}(ctx, app.StorageService, cleanupInterval, app.Config.LogRetentionDuration)
+	}(cleanupCtx, cleanupInterval)
 
+	var err error
 	select {
 	case <-ctx.Done():
-		app.Logger.Info("shutting down server")
+	case err = <-serverErr:
+	}
 
-		timeout, cancel := context.WithTimeout(context.Background(), time.Second*10)
-		defer cancel()
+	app.shutdown(server, stopCleanup, cleanupDone, cleanupRunning)
+	return err
+}
 
-		return server.Shutdown(timeout)
+// RemoveOldLogFiles removes all log files older than the retention duration that aren't
+// referenced by a pinned or extended log bundle. Returns the number of removed log files.
+func (app *App) RemoveOldLogFiles(ctx context.Context) (int, error) {
+	keptLogFileIds, err := app.RedisService.GetPinnedLogFiles(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get pinned log files: %w", err)
 	}
+
+	extendedLogFileIds, err := app.RedisService.GetExtendedLogFiles(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get extended log files: %w", err)
+	}
+
+	for logFileId := range extendedLogFileIds {
+		keptLogFileIds[logFileId] = struct{}{}
+	}
+
+	before := time.Now().Add(-app.Config.LogRetentionDuration)
+	return app.StorageService.RemoveOldLogFiles(before, keptLogFileIds)
+}
+
+// RecordStorageUsage stores a sample of the current storage usage for the admin dashboard.
+func (app *App) RecordStorageUsage(ctx context.Context) error {
+	usage, err := app.StorageService.GetStorageUsage()
+	if err != nil {
+		return err
+	}
+
+	return app.RedisService.RecordStorageUsage(ctx, redis.StorageUsageSample{
+		Time:      time.Now(),
+		Bytes:     usage.Bytes,
+		FileCount: usage.FileCount,
+	})
+}
+
+// upper limit of the time abandoned handlers get to return before redis is closed, their
+// connections are closed, so they usually return right away
+const abandonedRequestsTimeout = 5 * time.Second
+
+// shutdown stops the application in order: stop accepting new requests, drain in-flight
+// uploads, finish pending commits and webhook deliveries, stop the cleanup goroutine and
+// finally close redis.
+// All steps share a single timeout, any work that's still running afterward is abandoned.
+// Abandoned uploads can't start new commits or webhook deliveries, they fail with 503.
+// Redis is only closed once the abandoned handlers returned or abandonedRequestsTimeout
+// passed.
+func (app *App) shutdown(server *http.Server, stopCleanup context.CancelFunc, cleanupDone <-chan struct{}, cleanupRunning *atomic.Bool) {
+	shutdownTimeout := app.Config.ShutdownTimeout
+	app.Logger.Info("shutting down", slog.Duration("shutdownTimeout", shutdownTimeout))
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	app.Logger.Info("stopping server and draining in-flight requests")
+	if err := server.Shutdown(ctx); err != nil {
+		app.Logger.Error("failed to drain in-flight requests, abandoning them", slog.Int64("inFlightUploads", app.ApiService.InFlightUploads()), utils.ErrAttr(err))
+		_ = server.Close()
+	}
+
+	app.Logger.Info("waiting for pending commits")
+	if err := app.StorageService.WaitForPendingCommits(ctx); err != nil {
+		app.Logger.Error("failed to wait for pending commits", utils.ErrAttr(err))
+	}
+
+	app.Logger.Info("waiting for pending webhook deliveries")
+	if err := app.WebhookService.WaitForPendingDeliveries(ctx); err != nil {
+		app.Logger.Error("failed to wait for pending webhook deliveries", utils.ErrAttr(err))
+	}
+
+	app.Logger.Info("stopping cleanup goroutine")
+	stopCleanup()
+
+	select {
+	case <-cleanupDone:
+	case <-ctx.Done():
+		if cleanupRunning.Load() {
+			app.Logger.Error("abandoning running cleanup of old log files")
+		} else {
+			<-cleanupDone
+		}
+	}
+
+	app.Logger.Info("waiting for abandoned requests")
+	requestsCtx, cancelRequests := context.WithTimeout(context.Background(), abandonedRequestsTimeout)
+	defer cancelRequests()
+
+	if err := app.ApiService.WaitForRequests(requestsCtx); err != nil {
+		app.Logger.Error("failed to wait for abandoned requests, closing redis anyway", utils.ErrAttr(err))
+	}
+
+	app.Logger.Info("closing redis client")
+	app.RedisService.Close()
+
+	app.Logger.Info("shutdown complete")
 }
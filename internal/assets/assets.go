@@ -0,0 +1,100 @@
+// Package assets contains the static files of the frontend. Every file is served under a
+// content-hashed name, so it can be cached forever.
+package assets
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+)
+
+// PathPrefix is the URL prefix of all static files.
+const PathPrefix = "/static/"
+
+//go:embed static
+var staticFiles embed.FS
+
+type asset struct {
+	name        string
+	contentType string
+	content     []byte
+}
+
+var (
+	// logical name (app.js) to hashed name (app.0123456789ab.js)
+	hashedNames = make(map[string]string)
+	// hashed name to asset
+	assetsByHashedName = make(map[string]asset)
+)
+
+func init() {
+	entries, err := fs.ReadDir(staticFiles, "static")
+	if err != nil {
+		panic(fmt.Errorf("failed to read embedded static files: %w", err))
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		name := entry.Name()
+		content, err := staticFiles.ReadFile(path.Join("static", name))
+		if err != nil {
+			panic(fmt.Errorf("failed to read embedded static file `%s`: %w", name, err))
+		}
+
+		hash := sha256.Sum256(content)
+		extension := path.Ext(name)
+		hashedName := fmt.Sprintf("%s.%s%s", strings.TrimSuffix(name, extension), hex.EncodeToString(hash[:6]), extension)
+
+		contentType := mime.TypeByExtension(extension)
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+
+		hashedNames[name] = hashedName
+		assetsByHashedName[hashedName] = asset{
+			name:        name,
+			contentType: contentType,
+			content:     content,
+		}
+	}
+}
+
+// Path returns the URL path of the static file with the given name, e.g. `app.js`.
+// Panics if the file doesn't exist.
+func Path(name string) string {
+	hashedName, ok := hashedNames[name]
+	if !ok {
+		panic(fmt.Sprintf("unknown static file `%s`", name))
+	}
+
+	return PathPrefix + hashedName
+}
+
+// Handler serves the static files under their hashed names.
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hashedName := strings.TrimPrefix(r.URL.Path, PathPrefix)
+
+		a, ok := assetsByHashedName[hashedName]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", a.contentType)
+		w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		http.ServeContent(w, r, a.name, time.UnixMilli(0), bytes.NewReader(a.content))
+	})
+}
@@ -0,0 +1,366 @@
+(function () {
+    "use strict";
+
+    // Lazily loads the contents of every element with a `data-src` or `data-fragment`
+    // attribute once it becomes visible. `data-src` responses are inserted as text,
+    // `data-fragment` responses are HTML rendered by the server.
+    async function load(element) {
+        const src = element.getAttribute("data-src") || element.getAttribute("data-fragment");
+
+        try {
+            const response = await fetch(src);
+            if (!response.ok) {
+                throw new Error(`${response.status} ${response.statusText}`);
+            }
+
+            const body = await response.text();
+            if (element.hasAttribute("data-fragment")) {
+                element.innerHTML = body;
+            } else {
+                element.textContent = body;
+            }
+
+            element.classList.add("loaded");
+        } catch (err) {
+            element.textContent = `failed to load ${src}: ${err.message}`;
+            element.classList.add("error");
+        }
+    }
+
+    function initLazyLoading() {
+        const elements = document.querySelectorAll("[data-src], [data-fragment]");
+
+        if (!("IntersectionObserver" in window)) {
+            elements.forEach(load);
+            return;
+        }
+
+        const observer = new IntersectionObserver((entries) => {
+            for (const entry of entries) {
+                if (!entry.isIntersecting) {
+                    continue;
+                }
+
+                observer.unobserve(entry.target);
+                load(entry.target);
+            }
+        });
+
+        elements.forEach((element) => observer.observe(element));
+    }
+
+    // Parses `#L120` and `#L120-L180` into a range of line numbers.
+    function parseLineRange(hash) {
+        const match = /^#L(\d+)(?:-L(\d+))?$/.exec(hash);
+        if (!match) {
+            return null;
+        }
+
+        const first = parseInt(match[1], 10);
+        const last = match[2] ? parseInt(match[2], 10) : first;
+        return first <= last ? {first, last} : {first: last, last: first};
+    }
+
+    function formatLineRange(range) {
+        return range.first === range.last ? `#L${range.first}` : `#L${range.first}-L${range.last}`;
+    }
+
+    function initLineSelection() {
+        const view = document.querySelector(".log-view[data-selectable]");
+        if (!view) {
+            return;
+        }
+
+        const page = parseInt(view.getAttribute("data-page"), 10);
+        const pageSize = parseInt(view.getAttribute("data-page-size"), 10);
+        const pageCount = parseInt(view.getAttribute("data-page-count"), 10);
+        // page numbers of filtered views don't map to line numbers
+        const isFiltered = view.hasAttribute("data-filtered");
+        let selected = null;
+
+        function highlight() {
+            view.querySelectorAll("tr.highlighted").forEach((row) => row.classList.remove("highlighted"));
+
+            selected = parseLineRange(window.location.hash);
+            if (!selected) {
+                return;
+            }
+
+            // the line range might be on a different page
+            const targetPage = Math.min(Math.ceil(selected.first / pageSize), pageCount);
+            if (!isFiltered && targetPage !== page) {
+                const url = new URL(window.location.href);
+                url.searchParams.set("page", targetPage.toString());
+                window.location.replace(url.toString());
+                return;
+            }
+
+            let firstRow = null;
+            for (let line = selected.first; line <= selected.last; line++) {
+                const row = document.getElementById(`L${line}`);
+                if (!row) {
+                    break;
+                }
+
+                // selected stack frames are unfolded
+                const body = row.closest("tbody");
+                if (body.hidden) {
+                    body.hidden = false;
+                    body.previousElementSibling.querySelector("[data-fold]").classList.add("unfolded");
+                }
+
+                row.classList.add("highlighted");
+                firstRow = firstRow || row;
+            }
+
+            if (firstRow) {
+                firstRow.scrollIntoView({block: "center"});
+            }
+        }
+
+        view.addEventListener("click", (event) => {
+            const link = event.target.closest("a[data-line]");
+            if (!link) {
+                return;
+            }
+
+            event.preventDefault();
+
+            const line = parseInt(link.getAttribute("data-line"), 10);
+            let range = {first: line, last: line};
+            if (event.shiftKey && selected) {
+                range = {first: Math.min(selected.first, line), last: Math.max(selected.first, line)};
+            }
+
+            history.replaceState(null, "", formatLineRange(range));
+            highlight();
+        });
+
+        window.addEventListener("hashchange", highlight);
+        highlight();
+    }
+
+    function initCopyLink() {
+        document.querySelectorAll("[data-copy-link]").forEach((button) => {
+            button.addEventListener("click", async () => {
+                const label = button.textContent;
+
+                try {
+                    await navigator.clipboard.writeText(window.location.href);
+                    button.textContent = "Copied!";
+                } catch (err) {
+                    button.textContent = "Copy failed";
+                }
+
+                setTimeout(() => button.textContent = label, 1500);
+            });
+        });
+    }
+
+    function initAutoSubmit() {
+        document.querySelectorAll("select[data-auto-submit]").forEach((select) => {
+            select.addEventListener("change", () => select.form.requestSubmit());
+        });
+    }
+
+    // Asks for confirmation before submitting forms like the deletion of a log bundle.
+    function initConfirm() {
+        document.querySelectorAll("form[data-confirm]").forEach((form) => {
+            form.addEventListener("submit", (event) => {
+                if (!window.confirm(form.dataset.confirm)) {
+                    event.preventDefault();
+                }
+            });
+        });
+    }
+
+    // Shows the collapsed rows after the toggle of the diff view.
+    function initExpand() {
+        document.querySelectorAll("[data-expand]").forEach((button) => {
+            button.addEventListener("click", () => {
+                const toggle = button.closest("tbody");
+                toggle.nextElementSibling.hidden = false;
+                toggle.remove();
+            });
+        });
+    }
+
+    // Folds and unfolds the stack frames after the toggle.
+    function initFolding() {
+        document.addEventListener("click", (event) => {
+            const button = event.target.closest("[data-fold]");
+            if (!button) {
+                return;
+            }
+
+            const frames = button.closest("tbody").nextElementSibling;
+            frames.hidden = !frames.hidden;
+            button.classList.toggle("unfolded", !frames.hidden);
+        });
+    }
+
+    function formatBytes(size) {
+        const units = ["KiB", "MiB", "GiB", "TiB"];
+        if (size < 1024) {
+            return `${size} B`;
+        }
+
+        let value = size / 1024;
+        let unit = 0;
+        while (value >= 1024 && unit < units.length - 1) {
+            value /= 1024;
+            unit++;
+        }
+
+        return `${value.toFixed(1)} ${units[unit]}`;
+    }
+
+    // Uploads the selected or dropped files with a single request and redirects to the new
+    // bundle. The progress of the request is split across the files in the order they're sent.
+    function initUpload() {
+        const form = document.querySelector("form[data-upload]");
+        if (!form) {
+            return;
+        }
+
+        const input = form.querySelector("input[type=file]");
+        const dropZone = form.querySelector(".upload-drop-zone");
+        const list = form.querySelector(".upload-files");
+        const error = form.querySelector(".upload-error");
+        const button = form.querySelector("button[type=submit]");
+        const uploadKey = form.querySelector("input[data-upload-key]");
+        const maxFiles = parseInt(form.getAttribute("data-max-files"), 10);
+        const maxFileSize = parseInt(form.getAttribute("data-max-file-size"), 10);
+        let files = [];
+
+        // the button is enabled in the markup for browsers without JavaScript, here it's only
+        // enabled once valid files are selected
+        button.disabled = true;
+
+        function showError(message) {
+            error.textContent = message;
+            error.hidden = !message;
+        }
+
+        function validate() {
+            if (files.length > maxFiles) {
+                return `At most ${maxFiles} files can be uploaded at once.`;
+            }
+
+            const tooLarge = files.filter((file) => file.size > maxFileSize);
+            if (tooLarge.length !== 0) {
+                const names = tooLarge.map((file) => file.name).join(", ");
+                return `${names} exceed${tooLarge.length === 1 ? "s" : ""} the limit of ${formatBytes(maxFileSize)} per file.`;
+            }
+
+            return "";
+        }
+
+        function setFiles(fileList) {
+            files = Array.from(fileList);
+
+            list.replaceChildren(...files.map((file) => {
+                const name = document.createElement("span");
+                name.textContent = file.name;
+
+                const size = document.createElement("span");
+                size.textContent = formatBytes(file.size);
+
+                const progress = document.createElement("progress");
+                progress.max = Math.max(file.size, 1);
+                progress.value = 0;
+
+                const item = document.createElement("li");
+                item.classList.toggle("invalid", file.size > maxFileSize);
+                item.append(name, size, progress);
+                return item;
+            }));
+
+            const message = validate();
+            showError(message);
+            button.disabled = files.length === 0 || message !== "";
+        }
+
+        input.addEventListener("change", () => setFiles(input.files));
+
+        ["dragenter", "dragover"].forEach((type) => dropZone.addEventListener(type, (event) => {
+            event.preventDefault();
+            dropZone.classList.add("dragging");
+        }));
+
+        ["dragleave", "drop"].forEach((type) => dropZone.addEventListener(type, () => {
+            dropZone.classList.remove("dragging");
+        }));
+
+        dropZone.addEventListener("drop", (event) => {
+            event.preventDefault();
+            input.files = event.dataTransfer.files;
+            setFiles(input.files);
+        });
+
+        form.addEventListener("submit", (event) => {
+            event.preventDefault();
+            if (files.length === 0 || validate()) {
+                return;
+            }
+
+            const data = new FormData();
+            files.forEach((file) => data.append("files", file, file.name));
+            const progresses = list.querySelectorAll("progress");
+
+            const request = new XMLHttpRequest();
+            request.open("POST", form.action);
+            request.setRequestHeader("Accept", "application/json");
+            if (uploadKey && uploadKey.value) {
+                request.setRequestHeader("Authorization", `Bearer ${uploadKey.value}`);
+            }
+
+            request.upload.addEventListener("progress", (progressEvent) => {
+                // the multipart headers are small compared to the files and are ignored
+                let remaining = progressEvent.loaded;
+                files.forEach((file, i) => {
+                    const loaded = Math.min(remaining, file.size);
+                    progresses[i].value = loaded;
+                    remaining -= loaded;
+                });
+            });
+
+            request.addEventListener("load", () => {
+                if (request.status !== 200) {
+                    showError(request.responseText.trim() || `${request.status} ${request.statusText}`);
+                    button.disabled = false;
+                    return;
+                }
+
+                const response = JSON.parse(request.responseText);
+                window.location.assign(`/view/bundle/${response.bundleId}`);
+            });
+
+            request.addEventListener("error", () => {
+                showError("The upload failed, check your connection and try again.");
+                button.disabled = false;
+            });
+
+            showError("");
+            button.disabled = true;
+            request.send(data);
+        });
+    }
+
+    function init() {
+        initLazyLoading();
+        initLineSelection();
+        initCopyLink();
+        initAutoSubmit();
+        initConfirm();
+        initExpand();
+        initFolding();
+        initUpload();
+    }
+
+    if (document.readyState === "loading") {
+        document.addEventListener("DOMContentLoaded", init);
+    } else {
+        init();
+    }
+})();
@@ -0,0 +1,95 @@
+// Package charset detects the character encoding of log files and decodes them to UTF-8.
+package charset
+
+import (
+	"bytes"
+	"unicode/utf8"
+)
+
+// Encoding is the character encoding of a log file.
+type Encoding string
+
+const (
+	// Unknown is the encoding of log files that were staged before encodings were detected,
+	// they are treated as UTF-8.
+	Unknown     Encoding = ""
+	Utf8        Encoding = "utf-8"
+	Utf16LE     Encoding = "utf-16le"
+	Utf16BE     Encoding = "utf-16be"
+	Windows1252 Encoding = "windows-1252"
+)
+
+var (
+	utf8ByteOrderMark    = []byte{0xef, 0xbb, 0xbf}
+	utf16LEByteOrderMark = []byte{0xff, 0xfe}
+	utf16BEByteOrderMark = []byte{0xfe, 0xff}
+)
+
+// IsUtf8 returns true if the log file can be read without decoding it.
+func (e Encoding) IsUtf8() bool {
+	return e == Unknown || e == Utf8
+}
+
+// Detector detects the encoding of the data written to it. UTF-16 is recognized by its byte
+// order mark, data that isn't valid UTF-8 is assumed to be Windows-1252, which is the most
+// common legacy encoding of Windows applications and a superset of Latin-1.
+type Detector struct {
+	head    []byte
+	pending []byte
+	invalid bool
+}
+
+func NewDetector() *Detector {
+	return &Detector{}
+}
+
+func (d *Detector) Write(data []byte) (int, error) {
+	n := len(data)
+	if missing := len(utf8ByteOrderMark) - len(d.head); missing > 0 {
+		d.head = append(d.head, data[:min(missing, len(data))]...)
+	}
+
+	if d.invalid {
+		return n, nil
+	}
+
+	// runes can be split across multiple writes
+	if len(d.pending) != 0 {
+		data = append(d.pending, data...)
+		d.pending = nil
+	}
+
+	if utf8.Valid(data) {
+		return n, nil
+	}
+
+	for i := 0; i < len(data); {
+		r, size := utf8.DecodeRune(data[i:])
+		if r == utf8.RuneError && size == 1 {
+			if !utf8.FullRune(data[i:]) {
+				d.pending = bytes.Clone(data[i:])
+				break
+			}
+
+			d.invalid = true
+			break
+		}
+
+		i += size
+	}
+
+	return n, nil
+}
+
+// Encoding returns the encoding of all data written so far.
+func (d *Detector) Encoding() Encoding {
+	if encoding := ByteOrderMark(d.head); encoding != Unknown {
+		return encoding
+	}
+
+	if d.invalid || len(d.pending) != 0 {
+		return Windows1252
+	}
+
+	return Utf8
+}
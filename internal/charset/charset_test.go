@@ -0,0 +1,107 @@
+package charset
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"testing/iotest"
+)
+
+func TestDetector(t *testing.T) {
+	tests := []struct {
+		name     string
+		writes   []string
+		expected Encoding
+	}{
+		{name: "empty", writes: nil, expected: Utf8},
+		{name: "ascii", writes: []string{"plain text\n"}, expected: Utf8},
+		{name: "utf-8 rune split across writes", writes: []string{"gr\xc3", "\xbc\xc3\x9fe"}, expected: Utf8},
+		{name: "utf-8 byte order mark", writes: []string{"\xef\xbb", "\xbftext"}, expected: Utf8},
+		{name: "utf-16le", writes: []string{"\xff", "\xfet\x00"}, expected: Utf16LE},
+		{name: "utf-16be", writes: []string{"\xfe\xff\x00t"}, expected: Utf16BE},
+		{name: "latin-1", writes: []string{"gr\xfc\xdfe\n"}, expected: Windows1252},
+		{name: "incomplete rune at the end", writes: []string{"text \xc3"}, expected: Windows1252},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			detector := NewDetector()
+			for _, data := range test.writes {
+				if _, err := detector.Write([]byte(data)); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if actual := detector.Encoding(); actual != test.expected {
+				t.Errorf("expected `%s`, got `%s`", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestUtf16RoundTrip(t *testing.T) {
+	// characters outside of the BMP are encoded as surrogate pairs
+	text := "größe 😀 €\r\nline 2\n"
+
+	for _, encoding := range []Encoding{Utf16LE, Utf16BE} {
+		t.Run(string(encoding), func(t *testing.T) {
+			var encoded bytes.Buffer
+			writer := NewWriter(&encoded, encoding)
+
+			// a single byte at a time splits every multibyte rune
+			for i := 0; i < len(text); i++ {
+				if _, err := writer.Write([]byte{text[i]}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if ByteOrderMark(encoded.Bytes()) != encoding {
+				t.Fatalf("expected a byte order mark of `%s`, got % x", encoding, encoded.Bytes()[:2])
+			}
+
+			decoded, err := io.ReadAll(NewReader(iotest.OneByteReader(&encoded), encoding))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(decoded) != text {
+				t.Errorf("expected %q, got %q", text, decoded)
+			}
+		})
+	}
+}
+
+func TestDecodeIncompleteUtf16(t *testing.T) {
+	// a high surrogate without its low surrogate and a single byte at the end
+	decoded, err := io.ReadAll(NewReader(bytes.NewReader([]byte{0xff, 0xfe, 'a', 0, 0x3d, 0xd8, 'b'}), Utf16LE))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := "a�"; string(decoded) != expected {
+		t.Errorf("expected %q, got %q", expected, decoded)
+	}
+}
+
+func TestDecodeWindows1252(t *testing.T) {
+	decoded, err := io.ReadAll(NewReader(bytes.NewReader([]byte("\x80 \x93quoted\x94 gr\xf6\xdfe \x81")), Windows1252))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := "€ “quoted” größe \u0081"; string(decoded) != expected {
+		t.Errorf("expected %q, got %q", expected, decoded)
+	}
+}
+
+func TestUtf8IsUnchanged(t *testing.T) {
+	reader := bytes.NewReader([]byte("text"))
+	if NewReader(reader, Utf8) != io.Reader(reader) || NewReader(reader, Unknown) != io.Reader(reader) {
+		t.Error("expected UTF-8 readers to be returned unchanged")
+	}
+
+	var buffer bytes.Buffer
+	if NewWriter(&buffer, Utf8) != io.Writer(&buffer) || NewWriter(&buffer, Windows1252) != io.Writer(&buffer) {
+		t.Error("expected writers of other encodings than UTF-16 to be returned unchanged")
+	}
+}
@@ -0,0 +1,136 @@
+package charset
+
+import (
+	"bytes"
+	"io"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// size of the chunks read from the underlying reader
+const decodeChunkSize = 32 * 1024
+
+// characters of Windows-1252 that differ from Latin-1, the five unassigned bytes are
+// decoded to the control characters with the same code point like browsers do
+var windows1252 = [32]rune{
+	'€', 0x81, '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', 0x8d, 'Ž', 0x8f,
+	0x90, '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', 0x9d, 'ž', 'Ÿ',
+}
+
+// NewReader returns a reader that decodes the data of the reader from the encoding to
+// UTF-8. Byte order marks are dropped. Readers of UTF-8 data are returned unchanged.
+func NewReader(reader io.Reader, encoding Encoding) io.Reader {
+	switch encoding {
+	case Utf16LE, Utf16BE:
+		return &decoder{reader: reader, decode: (&utf16Decoder{bigEndian: encoding == Utf16BE}).decode}
+	case Windows1252:
+		return &decoder{reader: reader, decode: decodeWindows1252}
+	default:
+		return reader
+	}
+}
+
+type decoder struct {
+	reader io.Reader
+	decode func(output []byte, input []byte, final bool) []byte
+	input  []byte
+	output bytes.Buffer
+	err    error
+}
+
+func (d *decoder) Read(p []byte) (int, error) {
+	for d.output.Len() == 0 && d.err == nil {
+		if d.input == nil {
+			d.input = make([]byte, decodeChunkSize)
+		}
+
+		n, err := d.reader.Read(d.input)
+		d.err = err
+		d.output.Write(d.decode(d.output.AvailableBuffer(), d.input[:n], err != nil))
+	}
+
+	if d.output.Len() != 0 {
+		return d.output.Read(p)
+	}
+
+	return 0, d.err
+}
+
+func decodeWindows1252(output []byte, input []byte, _ bool) []byte {
+	for _, b := range input {
+		switch {
+		case b < 0x80:
+			output = append(output, b)
+		case b < 0xa0:
+			output = utf8.AppendRune(output, windows1252[b-0x80])
+		default:
+			output = utf8.AppendRune(output, rune(b))
+		}
+	}
+
+	return output
+}
+
+// utf16Decoder keeps the state between chunks, which can end in the middle of a code unit
+// or a surrogate pair.
+type utf16Decoder struct {
+	bigEndian bool
+	started   bool
+	pending   []byte
+	surrogate rune
+}
+
+func (d *utf16Decoder) decode(output []byte, input []byte, final bool) []byte {
+	if len(d.pending) != 0 {
+		input = append(d.pending, input...)
+		d.pending = nil
+	}
+
+	for len(input) >= 2 {
+		var unit rune
+		if d.bigEndian {
+			unit = rune(input[0])<<8 | rune(input[1])
+		} else {
+			unit = rune(input[1])<<8 | rune(input[0])
+		}
+
+		input = input[2:]
+
+		if !d.started {
+			d.started = true
+			if unit == 0xfeff {
+				continue
+			}
+		}
+
+		if d.surrogate != 0 {
+			r := utf16.DecodeRune(d.surrogate, unit)
+			d.surrogate = 0
+			if r != utf8.RuneError {
+				output = utf8.AppendRune(output, r)
+				continue
+			}
+
+			output = utf8.AppendRune(output, utf8.RuneError)
+		}
+
+		if utf16.IsSurrogate(unit) {
+			d.surrogate = unit
+			continue
+		}
+
+		output = utf8.AppendRune(output, unit)
+	}
+
+	if len(input) != 0 {
+		d.pending = append(d.pending, input...)
+	}
+
+	if final && (d.surrogate != 0 || len(d.pending) != 0) {
+		output = utf8.AppendRune(output, utf8.RuneError)
+		d.surrogate = 0
+		d.pending = nil
+	}
+
+	return output
+}
@@ -0,0 +1,90 @@
+package charset
+
+import (
+	"bytes"
+	"io"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// ByteOrderMark returns the encoding of the byte order mark at the start of the data, or
+// Unknown if the data doesn't start with a UTF-16 byte order mark.
+func ByteOrderMark(head []byte) Encoding {
+	switch {
+	case bytes.HasPrefix(head, utf16LEByteOrderMark):
+		return Utf16LE
+	case bytes.HasPrefix(head, utf16BEByteOrderMark):
+		return Utf16BE
+	default:
+		return Unknown
+	}
+}
+
+// NewWriter returns a writer that encodes the UTF-8 data written to it to the encoding, it
+// reverses NewReader. The byte order mark is written before the first data. Writers of
+// UTF-8 data are returned unchanged.
+func NewWriter(writer io.Writer, encoding Encoding) io.Writer {
+	switch encoding {
+	case Utf16LE, Utf16BE:
+		return &utf16Encoder{writer: writer, bigEndian: encoding == Utf16BE}
+	default:
+		return writer
+	}
+}
+
+// utf16Encoder keeps runes that are split across writes until they are complete. Invalid
+// UTF-8 is encoded as the replacement character.
+type utf16Encoder struct {
+	writer    io.Writer
+	bigEndian bool
+	started   bool
+	pending   []byte
+	output    []byte
+}
+
+func (e *utf16Encoder) Write(data []byte) (int, error) {
+	n := len(data)
+	if len(e.pending) != 0 {
+		data = append(e.pending, data...)
+		e.pending = nil
+	}
+
+	output := e.output[:0]
+	if !e.started {
+		e.started = true
+		output = e.appendUnit(output, 0xfeff)
+	}
+
+	for len(data) != 0 {
+		if !utf8.FullRune(data) {
+			e.pending = bytes.Clone(data)
+			break
+		}
+
+		r, size := utf8.DecodeRune(data)
+		data = data[size:]
+
+		if r1, r2 := utf16.EncodeRune(r); r1 != utf8.RuneError {
+			output = e.appendUnit(output, r1)
+			output = e.appendUnit(output, r2)
+			continue
+		}
+
+		output = e.appendUnit(output, r)
+	}
+
+	e.output = output
+	if _, err := e.writer.Write(output); err != nil {
+		return 0, err
+	}
+
+	return n, nil
+}
+
+func (e *utf16Encoder) appendUnit(output []byte, unit rune) []byte {
+	if e.bigEndian {
+		return append(output, byte(unit>>8), byte(unit))
+	}
+
+	return append(output, byte(unit), byte(unit>>8))
+}
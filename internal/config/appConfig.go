@@ -9,6 +9,7 @@ type AppConfig struct {
 
 	LogRetentionDuration time.Duration `env:"LOG_RETENTION_DURATION, default=336h"`
 	CleanupInterval      time.Duration `env:"CLEANUP_INTERVAL, default=10m"`
+	ShutdownTimeout      time.Duration `env:"SHUTDOWN_TIMEOUT, default=30s"`
 
 	SingleFileSizeLimit uint64 `env:"SINGLE_FILE_SIZE_LIMIT, default=1048576"`
 	MaxFileCount        uint16 `env:"MAX_FILE_COUNT_PER_BUNDLE, default=5"`
@@ -17,6 +18,44 @@ type AppConfig struct {
 	StagingPath string `env:"STAGING_PATH, required"`
 	StoragePath string `env:"STORAGE_PATH, required"`
 
+	// names of the built-in detectors, see redact.BuiltinDetectorNames
+	RedactionDetectors []string `env:"REDACTION_DETECTORS, default=jwt,bearer,aws,email,ip,username"`
+	// custom detectors as `name:regex;name:regex`
+	RedactionPatterns map[string]string `env:"REDACTION_PATTERNS, delimiter=;, separator=:"`
+	// stores log files exactly as they were uploaded
+	AllowUnredactedLogs bool `env:"ALLOW_UNREDACTED_LOGS, default=false"`
+
+	// tokens for the administration endpoints, which are disabled if no tokens are set
+	AdminTokens []string `env:"ADMIN_TOKENS"`
+	// keys identifying uploaders as `name:key,name:key`, only the name is stored with the bundle
+	UploadKeys       map[string]string `env:"UPLOAD_KEYS"`
+	RequireUploadKey bool              `env:"REQUIRE_UPLOAD_KEY, default=false"`
+
+	// inspections of staged log files before they are committed, see ingest.BuiltinHookNames
+	IngestHooks []string `env:"INGEST_HOOKS, default=magic,text"`
+	// executable run with the arguments and the path of every staged log file as the last
+	// argument, exit code 1 rejects it
+	ScanCommand string `env:"SCAN_COMMAND"`
+	// arguments of the scan command as `arg;arg`, so they can contain spaces
+	ScanCommandArgs []string `env:"SCAN_COMMAND_ARGS, delimiter=;"`
+	// ICAP service scanning every staged log file, like `icap://localhost:1344/avscan`
+	ScanIcapUrl string        `env:"SCAN_ICAP_URL"`
+	ScanTimeout time.Duration `env:"SCAN_TIMEOUT, default=30s"`
+	// directory of rejected log files, defaults to `quarantine` in the staging directory
+	QuarantinePath string `env:"QUARANTINE_PATH"`
+
+	// URL of the server as seen by other services, like `https://logs.example.com`, used for links in webhooks
+	PublicUrl string `env:"PUBLIC_URL"`
+
+	// URLs notified after a log bundle is committed, the requests are signed with the secret.
+	// Pending deliveries are only kept in memory and are recorded as failed if they don't finish
+	// before the shutdown timeout, they are not resumed after a restart.
+	WebhookUrls           []string      `env:"WEBHOOK_URLS"`
+	WebhookSecret         string        `env:"WEBHOOK_SECRET"`
+	WebhookMaxAttempts    uint          `env:"WEBHOOK_MAX_ATTEMPTS, default=5"`
+	WebhookInitialBackoff time.Duration `env:"WEBHOOK_INITIAL_BACKOFF, default=5s"`
+	WebhookTimeout        time.Duration `env:"WEBHOOK_TIMEOUT, default=10s"`
+
 	DirectoryPermissions uint32 `env:"DIRECTORY_UMASK"`
 	FilePermissions      uint32 `env:"FILE_MASK"`
 }
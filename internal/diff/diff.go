@@ -0,0 +1,186 @@
+// Package diff computes line-level differences between log files.
+package diff
+
+// Operation is the kind of change of an Edit.
+type Operation uint8
+
+const (
+	OperationEqual Operation = iota
+	OperationDelete
+	OperationInsert
+)
+
+// Edit is a single line of the difference. Delete edits refer to a line on the left,
+// insert edits to a line on the right and equal edits to both.
+type Edit struct {
+	Operation Operation
+	// 0-based line indices, -1 if the line doesn't exist on that side
+	Left  int
+	Right int
+}
+
+// Lines computes the shortest edit script between the left and right lines using the
+// Myers algorithm. If more than maxDifferences lines are different, the lines after the
+// common prefix and suffix are all reported as deleted and inserted, and complete is false.
+func Lines(left, right []string, maxDifferences int) (edits []Edit, complete bool) {
+	// lines are compared as integers
+	ids := make(map[string]int)
+	toIds := func(lines []string) []int {
+		res := make([]int, len(lines))
+		for i, line := range lines {
+			id, ok := ids[line]
+			if !ok {
+				id = len(ids)
+				ids[line] = id
+			}
+
+			res[i] = id
+		}
+
+		return res
+	}
+
+	a, b := toIds(left), toIds(right)
+
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix += 1
+	}
+
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix += 1
+	}
+
+	edits = make([]Edit, 0, max(len(a), len(b)))
+	for i := 0; i < prefix; i++ {
+		edits = append(edits, Edit{Operation: OperationEqual, Left: i, Right: i})
+	}
+
+	middle, complete := myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix], maxDifferences)
+	for _, edit := range middle {
+		if edit.Left != -1 {
+			edit.Left += prefix
+		}
+
+		if edit.Right != -1 {
+			edit.Right += prefix
+		}
+
+		edits = append(edits, edit)
+	}
+
+	for i := 0; i < suffix; i++ {
+		edits = append(edits, Edit{Operation: OperationEqual, Left: len(a) - suffix + i, Right: len(b) - suffix + i})
+	}
+
+	return edits, complete
+}
+
+func replaceAll(a, b []int) []Edit {
+	edits := make([]Edit, 0, len(a)+len(b))
+	for i := range a {
+		edits = append(edits, Edit{Operation: OperationDelete, Left: i, Right: -1})
+	}
+
+	for i := range b {
+		edits = append(edits, Edit{Operation: OperationInsert, Left: -1, Right: i})
+	}
+
+	return edits
+}
+
+func myers(a, b []int, maxDifferences int) ([]Edit, bool) {
+	n, m := len(a), len(b)
+	if n == 0 || m == 0 {
+		return replaceAll(a, b), true
+	}
+
+	limit := min(n+m, maxDifferences)
+	offset := limit + 1
+	v := make([]int32, 2*limit+3)
+
+	// trace[d] contains the furthest reaching x for every diagonal k in [-d, d]
+	var trace [][]int32
+
+	for d := 0; d <= limit; d++ {
+		snapshot := make([]int32, 2*d+1)
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = int(v[offset+k+1])
+			} else {
+				x = int(v[offset+k-1]) + 1
+			}
+
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x += 1
+				y += 1
+			}
+
+			v[offset+k] = int32(x)
+			snapshot[k+d] = int32(x)
+
+			if x >= n && y >= m {
+				trace = append(trace, snapshot)
+				return backtrack(trace, n, m), true
+			}
+		}
+
+		trace = append(trace, snapshot)
+	}
+
+	return replaceAll(a, b), false
+}
+
+func backtrack(trace [][]int32, n, m int) []Edit {
+	var reversed []Edit
+	x, y := n, m
+
+	for d := len(trace) - 1; d > 0; d-- {
+		previous := trace[d-1]
+		getX := func(k int) int {
+			return int(previous[k+d-1])
+		}
+
+		k := x - y
+		var previousK int
+		if k == -d || (k != d && getX(k-1) < getX(k+1)) {
+			previousK = k + 1
+		} else {
+			previousK = k - 1
+		}
+
+		previousX := getX(previousK)
+		previousY := previousX - previousK
+
+		for x > previousX && y > previousY {
+			x -= 1
+			y -= 1
+			reversed = append(reversed, Edit{Operation: OperationEqual, Left: x, Right: y})
+		}
+
+		if x == previousX {
+			y -= 1
+			reversed = append(reversed, Edit{Operation: OperationInsert, Left: -1, Right: y})
+		} else {
+			x -= 1
+			reversed = append(reversed, Edit{Operation: OperationDelete, Left: x, Right: -1})
+		}
+	}
+
+	for x > 0 && y > 0 {
+		x -= 1
+		y -= 1
+		reversed = append(reversed, Edit{Operation: OperationEqual, Left: x, Right: y})
+	}
+
+	edits := make([]Edit, len(reversed))
+	for i, edit := range reversed {
+		edits[len(edits)-1-i] = edit
+	}
+
+	return edits
+}
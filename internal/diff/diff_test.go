@@ -0,0 +1,204 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+)
+
+// checkEdits fails if the edits don't turn the left lines into the right lines.
+func checkEdits(t *testing.T, left, right []string, edits []Edit) {
+	t.Helper()
+
+	nextLeft, nextRight := 0, 0
+	for _, edit := range edits {
+		switch edit.Operation {
+		case OperationEqual:
+			if edit.Left != nextLeft || edit.Right != nextRight || left[edit.Left] != right[edit.Right] {
+				t.Fatalf("invalid equal edit %v in %v", edit, edits)
+			}
+
+			nextLeft += 1
+			nextRight += 1
+		case OperationDelete:
+			if edit.Left != nextLeft || edit.Right != -1 {
+				t.Fatalf("invalid delete edit %v in %v", edit, edits)
+			}
+
+			nextLeft += 1
+		case OperationInsert:
+			if edit.Right != nextRight || edit.Left != -1 {
+				t.Fatalf("invalid insert edit %v in %v", edit, edits)
+			}
+
+			nextRight += 1
+		}
+	}
+
+	if nextLeft != len(left) || nextRight != len(right) {
+		t.Fatalf("edits %v don't cover all lines", edits)
+	}
+}
+
+func countDifferences(edits []Edit) int {
+	differences := 0
+	for _, edit := range edits {
+		if edit.Operation != OperationEqual {
+			differences += 1
+		}
+	}
+
+	return differences
+}
+
+func TestLines(t *testing.T) {
+	tests := []struct {
+		name        string
+		left        string
+		right       string
+		differences int
+	}{
+		{name: "equal", left: "a b c", right: "a b c", differences: 0},
+		{name: "both empty", left: "", right: "", differences: 0},
+		{name: "left empty", left: "", right: "a b", differences: 2},
+		{name: "right empty", left: "a b", right: "", differences: 2},
+		{name: "insert in the middle", left: "a b c", right: "a b x c", differences: 1},
+		{name: "delete at the start", left: "x a b", right: "a b", differences: 1},
+		{name: "changed line", left: "a b c", right: "a x c", differences: 2},
+		{name: "moved line", left: "a b c d", right: "b c d a", differences: 2},
+		{name: "classic example", left: "a b c a b b a", right: "c b a b a c", differences: 5},
+		{name: "duplicate lines", left: "a a a b", right: "a b b b", differences: 4},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			left, right := strings.Fields(test.left), strings.Fields(test.right)
+
+			edits, complete := Lines(left, right, 100)
+			if !complete {
+				t.Fatal("expected a complete diff")
+			}
+
+			checkEdits(t, left, right, edits)
+			if differences := countDifferences(edits); differences != test.differences {
+				t.Errorf("expected %d differences, got %d: %v", test.differences, differences, edits)
+			}
+		})
+	}
+}
+
+func TestLinesMaxDifferences(t *testing.T) {
+	left := strings.Fields("start a b c d e f end")
+	right := strings.Fields("start u v w x y z end")
+
+	edits, complete := Lines(left, right, 3)
+	if complete {
+		t.Fatal("expected an incomplete diff")
+	}
+
+	checkEdits(t, left, right, edits)
+
+	// the common prefix and suffix are still equal, everything between is replaced
+	if edits[0].Operation != OperationEqual || edits[len(edits)-1].Operation != OperationEqual {
+		t.Errorf("expected the common prefix and suffix to be equal: %v", edits)
+	}
+
+	if differences := countDifferences(edits); differences != 12 {
+		t.Errorf("expected 12 differences, got %d", differences)
+	}
+
+	// the same lines within the limit are compared completely
+	if _, complete := Lines(left, right, 12); !complete {
+		t.Error("expected a complete diff within the limit")
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	line := "2024-01-01T12:00:00.123Z request 3f2504e0-4f89-11d3-9a0c-0305e82c3301 bundle 01ARZ3NDEKTSV4RRFFQ69G5FAV at 12:00:01,5"
+
+	tests := []struct {
+		name     string
+		options  Options
+		expected string
+	}{
+		{
+			name:     "nothing ignored",
+			options:  Options{},
+			expected: line,
+		},
+		{
+			name:     "timestamps",
+			options:  Options{IgnoreTimestamps: true},
+			expected: "<timestamp> request 3f2504e0-4f89-11d3-9a0c-0305e82c3301 bundle 01ARZ3NDEKTSV4RRFFQ69G5FAV at <timestamp>",
+		},
+		{
+			name:     "ids",
+			options:  Options{IgnoreIds: true},
+			expected: "2024-01-01T12:00:00.123Z request <guid> bundle <ulid> at 12:00:01,5",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if actual := Normalize(line, test.options); actual != test.expected {
+				t.Errorf("expected `%s`, got `%s`", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestSideBySide(t *testing.T) {
+	left, right := strings.Fields("a b c d"), strings.Fields("a x c d e")
+	edits, _ := Lines(left, right, 100)
+
+	expected := []Row{
+		{Kind: RowEqual, Left: 0, Right: 0},
+		{Kind: RowChanged, Left: 1, Right: 1},
+		{Kind: RowEqual, Left: 2, Right: 2},
+		{Kind: RowEqual, Left: 3, Right: 3},
+		{Kind: RowInserted, Left: -1, Right: 4},
+	}
+
+	actual := SideBySide(edits)
+	if len(actual) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, actual)
+	}
+
+	for i := range actual {
+		if actual[i] != expected[i] {
+			t.Errorf("expected %v, got %v", expected, actual)
+		}
+	}
+}
+
+func TestSections(t *testing.T) {
+	rows := make([]Row, 10)
+	for i := range rows {
+		rows[i] = Row{Kind: RowEqual, Left: i, Right: i}
+	}
+
+	rows[5].Kind = RowChanged
+
+	sections := Sections(rows, 1)
+	expected := []struct {
+		length    int
+		collapsed bool
+	}{
+		{length: 4, collapsed: true},
+		{length: 3, collapsed: false},
+		{length: 3, collapsed: true},
+	}
+
+	if len(sections) != len(expected) {
+		t.Fatalf("expected %d sections, got %v", len(expected), sections)
+	}
+
+	for i, section := range sections {
+		if len(section.Rows) != expected[i].length || section.Collapsed != expected[i].collapsed {
+			t.Errorf("unexpected section %d: %v", i, section)
+		}
+	}
+
+	if sections := Sections(rows, -1); len(sections) != 1 || sections[0].Collapsed {
+		t.Errorf("expected a single expanded section, got %v", sections)
+	}
+}
@@ -0,0 +1,35 @@
+package diff
+
+import (
+	"regexp"
+)
+
+// Options decide which parts of a line are ignored when comparing lines.
+type Options struct {
+	IgnoreTimestamps bool
+	// IgnoreIds ignores ULIDs and GUIDs.
+	IgnoreIds bool
+}
+
+var (
+	// dates, times and both: 2024-01-01T12:00:00.123+02:00, 2024/01/01, 12:00:00,123
+	timestampPattern = regexp.MustCompile(`\d{4}[-/]\d{2}[-/]\d{2}(?:[T ]\d{2}:\d{2}(?::\d{2}(?:[.,]\d{1,9})?)?(?:\s?(?:Z|UTC|[+-]\d{2}:?\d{2}))?)?|\b\d{2}:\d{2}:\d{2}(?:[.,]\d{1,9})?\b`)
+	// GUIDs with and without braces: 3f2504e0-4f89-11d3-9a0c-0305e82c3301
+	guidPattern = regexp.MustCompile(`\b[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}\b`)
+	// ULIDs in Crockford's Base32: 01ARZ3NDEKTSV4RRFFQ69G5FAV
+	ulidPattern = regexp.MustCompile(`\b[0-7][0-9A-HJKMNP-TV-Za-hjkmnp-tv-z]{25}\b`)
+)
+
+// Normalize replaces the ignored parts of the line with placeholders.
+func Normalize(line string, options Options) string {
+	if options.IgnoreTimestamps {
+		line = timestampPattern.ReplaceAllLiteralString(line, "<timestamp>")
+	}
+
+	if options.IgnoreIds {
+		line = guidPattern.ReplaceAllLiteralString(line, "<guid>")
+		line = ulidPattern.ReplaceAllLiteralString(line, "<ulid>")
+	}
+
+	return line
+}
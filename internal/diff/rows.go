@@ -0,0 +1,102 @@
+package diff
+
+// RowKind is the kind of a row of a side-by-side diff.
+type RowKind uint8
+
+const (
+	RowEqual RowKind = iota
+	// RowChanged rows have a deleted line on the left and an inserted line on the right.
+	RowChanged
+	RowDeleted
+	RowInserted
+)
+
+// Row is a row of a side-by-side diff with 0-based line indices, -1 if the side is empty.
+type Row struct {
+	Kind  RowKind
+	Left  int
+	Right int
+}
+
+// Section is a run of rows that are either all unchanged or contain changes.
+type Section struct {
+	Rows []Row
+	// Collapsed sections only contain unchanged rows that are far away from any changes.
+	Collapsed bool
+}
+
+// SideBySide pairs deleted and inserted lines of the edits into rows.
+func SideBySide(edits []Edit) []Row {
+	rows := make([]Row, 0, len(edits))
+
+	for i := 0; i < len(edits); {
+		if edits[i].Operation == OperationEqual {
+			rows = append(rows, Row{Kind: RowEqual, Left: edits[i].Left, Right: edits[i].Right})
+			i += 1
+			continue
+		}
+
+		var deleted, inserted []int
+		for ; i < len(edits) && edits[i].Operation != OperationEqual; i++ {
+			if edits[i].Operation == OperationDelete {
+				deleted = append(deleted, edits[i].Left)
+			} else {
+				inserted = append(inserted, edits[i].Right)
+			}
+		}
+
+		for j := 0; j < max(len(deleted), len(inserted)); j++ {
+			row := Row{Kind: RowChanged, Left: -1, Right: -1}
+			if j < len(deleted) {
+				row.Left = deleted[j]
+			} else {
+				row.Kind = RowInserted
+			}
+
+			if j < len(inserted) {
+				row.Right = inserted[j]
+			} else {
+				row.Kind = RowDeleted
+			}
+
+			rows = append(rows, row)
+		}
+	}
+
+	return rows
+}
+
+// Sections splits the rows into sections and collapses unchanged rows that are more than
+// context rows away from a change. A negative context doesn't collapse anything.
+func Sections(rows []Row, context int) []Section {
+	if context < 0 {
+		return []Section{{Rows: rows}}
+	}
+
+	// visible rows are changes and unchanged rows close to a change
+	visible := make([]bool, len(rows))
+	for i, row := range rows {
+		if row.Kind == RowEqual {
+			continue
+		}
+
+		for j := max(0, i-context); j <= min(len(rows)-1, i+context); j++ {
+			visible[j] = true
+		}
+	}
+
+	var sections []Section
+	for i := 0; i < len(rows); {
+		start := i
+		for i < len(rows) && visible[i] == visible[start] {
+			i += 1
+		}
+
+		sections = append(sections, Section{
+			Rows:      rows[start:i],
+			Collapsed: !visible[start],
+		})
+	}
+
+	return sections
+}
@@ -0,0 +1,43 @@
+package index
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"simple-log-store/internal/jsonlines"
+	"simple-log-store/internal/logs"
+)
+
+// number of non-empty lines at the start of a file that are used to detect the format
+const formatDetectionLines = 100
+
+// DetectFormat detects the format of a log file from the first lines. Files are JSON lines if
+// every non-empty line is a JSON object.
+func DetectFormat(reader io.Reader, maxLineLength int) (logs.Format, error) {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), maxLineLength)
+
+	objectLines := 0
+	for objectLines < formatDetectionLines && scanner.Scan() {
+		line := scanner.Bytes()
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+
+		if _, ok := jsonlines.Parse(line); !ok {
+			return logs.FormatText, nil
+		}
+
+		objectLines += 1
+	}
+
+	if err := scanner.Err(); err != nil {
+		return logs.FormatUnknown, err
+	}
+
+	if objectLines == 0 {
+		return logs.FormatText, nil
+	}
+
+	return logs.FormatJsonLines, nil
+}
@@ -0,0 +1,100 @@
+package index
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"regexp"
+	"simple-log-store/internal/ansi"
+	"simple-log-store/internal/logs"
+)
+
+// only the beginning of a line is searched for generic level names to avoid matching the message
+const genericLevelSearchLength = 128
+
+var (
+	// JSON lines: {"level":"info"}, {"@l":"Warning"}, {"severity":"ERROR"}
+	jsonLevelPattern = regexp.MustCompile(`"(?:level|lvl|severity|levelname|loglevel|@l)"\s*:\s*"([A-Za-z]+)`)
+	// logfmt and Go slog text: level=INFO, lvl=warn
+	keyValueLevelPattern = regexp.MustCompile(`\b(?:level|lvl|severity)="?([A-Za-z]+)`)
+	// Serilog: [12:34:56 INF], [WRN]
+	serilogLevelPattern = regexp.MustCompile(`\[(?:[^\]]*\s)?(VRB|DBG|INF|WRN|ERR|FTL)\]`)
+	// NLog: 2024-01-01 12:00:00.0000|INFO|Logger|Message
+	nlogLevelPattern = regexp.MustCompile(`\|(TRACE|DEBUG|INFO|WARN|ERROR|FATAL)\|`)
+	// Python logging: WARNING:root:Message
+	pythonLevelPattern = regexp.MustCompile(`^(DEBUG|INFO|WARNING|ERROR|CRITICAL):`)
+	// log4j, Python logging with a custom format and everything else: 12:00:00,123 ERROR [main] Message, [Information]
+	genericLevelPattern = regexp.MustCompile(`(?:^|[\s\[(<|:-])(TRACE|DEBUG|INFO|INFORMATION|NOTICE|WARN|WARNING|ERROR|FATAL|CRITICAL|Trace|Verbose|Debug|Information|Warning|Error|Fatal|Critical)(?:$|[\s\])>|:-])`)
+)
+
+var levelPatterns = []*regexp.Regexp{
+	jsonLevelPattern,
+	keyValueLevelPattern,
+	serilogLevelPattern,
+	nlogLevelPattern,
+	pythonLevelPattern,
+}
+
+// ClassifyLine returns the level of the log line or logs.LevelUnknown if the line
+// doesn't contain a level.
+func ClassifyLine(line []byte) logs.Level {
+	// colored output from terminals has escape sequences between the level and its delimiters
+	if bytes.IndexByte(line, 0x1b) != -1 {
+		line = []byte(ansi.Strip(string(line)))
+	}
+
+	for _, pattern := range levelPatterns {
+		if level, ok := matchLevel(pattern, line); ok {
+			return level
+		}
+	}
+
+	prefix := line
+	if len(prefix) > genericLevelSearchLength {
+		prefix = prefix[:genericLevelSearchLength]
+	}
+
+	if level, ok := matchLevel(genericLevelPattern, prefix); ok {
+		return level
+	}
+
+	return logs.LevelUnknown
+}
+
+func matchLevel(pattern *regexp.Regexp, line []byte) (logs.Level, bool) {
+	match := pattern.FindSubmatch(line)
+	if match == nil {
+		return logs.LevelUnknown, false
+	}
+
+	return logs.ParseLevel(string(match[1]))
+}
+
+// ClassifyLines returns the level of every line. Lines without a level, like stack traces,
+// inherit the level of the previous line. Only lines with a level are counted.
+func ClassifyLines(reader io.Reader, maxLineLength int) ([]logs.Level, map[logs.Level]uint64, error) {
+	var levels []logs.Level
+	levelCounts := make(map[logs.Level]uint64)
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), maxLineLength)
+
+	previous := logs.LevelUnknown
+	for scanner.Scan() {
+		level := ClassifyLine(scanner.Bytes())
+		if level == logs.LevelUnknown {
+			level = previous
+		} else {
+			levelCounts[level] += 1
+		}
+
+		levels = append(levels, level)
+		previous = level
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	return levels, levelCounts, nil
+}
@@ -0,0 +1,90 @@
+package index
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"simple-log-store/internal/charset"
+)
+
+// LineOffsetInterval is the number of lines between two entries of the line offset index. Reading
+// a line starts at the closest indexed line before it, so at most this many lines are skipped.
+const LineOffsetInterval = 1000
+
+// FindLineOffsets returns the byte offset of the first line and of every LineOffsetInterval-th
+// line after it, in the original bytes of the log file. Newlines of UTF-16 are two bytes long.
+func FindLineOffsets(reader io.Reader, encoding charset.Encoding) ([]int64, error) {
+	bufferedReader := bufio.NewReaderSize(reader, 64*1024)
+
+	var readLine func() (int64, error)
+	switch encoding {
+	case charset.Utf16LE:
+		readLine = func() (int64, error) { return readUtf16Line(bufferedReader, []byte{'\n', 0}) }
+	case charset.Utf16BE:
+		readLine = func() (int64, error) { return readUtf16Line(bufferedReader, []byte{0, '\n'}) }
+	default:
+		readLine = func() (int64, error) { return readTextLine(bufferedReader) }
+	}
+
+	offsets := []int64{0}
+	var offset int64
+	var lineCount int
+	for {
+		n, err := readLine()
+		offset += n
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		lineCount += 1
+		if lineCount%LineOffsetInterval == 0 {
+			offsets = append(offsets, offset)
+		}
+	}
+
+	// a newline at the end of the log file doesn't start another line
+	if len(offsets) > 1 && offsets[len(offsets)-1] == offset {
+		offsets = offsets[:len(offsets)-1]
+	}
+
+	return offsets, nil
+}
+
+// readTextLine reads up to and including the next newline and returns the number of bytes read.
+// It returns io.EOF if the data ends before the next newline.
+func readTextLine(reader *bufio.Reader) (int64, error) {
+	var n int64
+	for {
+		line, err := reader.ReadSlice('\n')
+		n += int64(len(line))
+		if !errors.Is(err, bufio.ErrBufferFull) {
+			return n, err
+		}
+	}
+}
+
+// readUtf16Line reads code units up to and including the next newline and returns the number
+// of bytes read. It returns io.EOF if the data ends before the next newline.
+func readUtf16Line(reader *bufio.Reader, newline []byte) (int64, error) {
+	var n int64
+	unit := make([]byte, 2)
+	for {
+		read, err := io.ReadFull(reader, unit)
+		n += int64(read)
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return n, io.EOF
+		}
+
+		if err != nil {
+			return n, err
+		}
+
+		if unit[0] == newline[0] && unit[1] == newline[1] {
+			return n, nil
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package index
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"github.com/oklog/ulid/v2"
+	"simple-log-store/internal/charset"
+	"strings"
+	"testing"
+	"unicode/utf16"
+)
+
+// encodeUtf16 encodes the text without a byte order mark.
+func encodeUtf16(text string, order binary.ByteOrder) []byte {
+	units := utf16.Encode([]rune(text))
+	data := make([]byte, len(units)*2)
+	for i, unit := range units {
+		order.PutUint16(data[i*2:], unit)
+	}
+
+	return data
+}
+
+func TestFindLineOffsets(t *testing.T) {
+	// lines of different lengths, so wrong offsets don't match by accident
+	var builder strings.Builder
+	var lineStarts []int
+	for i := 0; i < 2*LineOffsetInterval+500; i++ {
+		lineStarts = append(lineStarts, builder.Len())
+		builder.WriteString(strings.Repeat("ä", i%7))
+		builder.WriteString("line\n")
+	}
+
+	text := builder.String()
+
+	tests := []struct {
+		name     string
+		encoding charset.Encoding
+		data     []byte
+		// number of bytes per byte of the UTF-8 text, all characters are in the BMP
+		scale func(offset int) int64
+	}{
+		{
+			name:     "utf-8",
+			encoding: charset.Utf8,
+			data:     []byte(text),
+			scale:    func(offset int) int64 { return int64(offset) },
+		},
+		{
+			name:     "utf-16le",
+			encoding: charset.Utf16LE,
+			data:     encodeUtf16(text, binary.LittleEndian),
+			scale:    func(offset int) int64 { return int64(len(encodeUtf16(text[:offset], binary.LittleEndian))) },
+		},
+		{
+			name:     "utf-16be",
+			encoding: charset.Utf16BE,
+			data:     encodeUtf16(text, binary.BigEndian),
+			scale:    func(offset int) int64 { return int64(len(encodeUtf16(text[:offset], binary.BigEndian))) },
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			offsets, err := FindLineOffsets(bytes.NewReader(test.data), test.encoding)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			expected := []int64{0, test.scale(lineStarts[LineOffsetInterval]), test.scale(lineStarts[2*LineOffsetInterval])}
+			if len(offsets) != len(expected) {
+				t.Fatalf("expected %v, got %v", expected, offsets)
+			}
+
+			for i := range offsets {
+				if offsets[i] != expected[i] {
+					t.Errorf("expected %v, got %v", expected, offsets)
+				}
+			}
+		})
+	}
+}
+
+func TestFindLineOffsetsTrailingNewline(t *testing.T) {
+	text := strings.Repeat("line\n", 2*LineOffsetInterval)
+
+	// the newline at the end doesn't start line 2001, so there's no offset for it
+	offsets, err := FindLineOffsets(strings.NewReader(text), charset.Utf8)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := []int64{0, int64(5 * LineOffsetInterval)}; len(offsets) != 2 || offsets[0] != expected[0] || offsets[1] != expected[1] {
+		t.Errorf("expected %v, got %v", expected, offsets)
+	}
+
+	// without the newline, the last line is still part of the index
+	offsets, err = FindLineOffsets(strings.NewReader(text+"last"), charset.Utf8)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(offsets) != 3 || offsets[2] != int64(10*LineOffsetInterval) {
+		t.Errorf("expected 3 offsets ending with %d, got %v", 10*LineOffsetInterval, offsets)
+	}
+
+	offsets, err = FindLineOffsets(strings.NewReader(""), charset.Utf8)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(offsets) != 1 || offsets[0] != 0 {
+		t.Errorf("expected a single offset for an empty file, got %v", offsets)
+	}
+}
+
+func TestGetLineOffsetRejectsLineZero(t *testing.T) {
+	// the line number is checked before the offsets are read
+	service := &Service{}
+	if _, _, err := service.GetLineOffset(context.Background(), ulid.Make(), 0); !errors.Is(err, errInvalidLineNumber) {
+		t.Errorf("expected errInvalidLineNumber, got %v", err)
+	}
+}
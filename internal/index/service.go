@@ -0,0 +1,292 @@
+package index
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"simple-log-store/internal/charset"
+	"simple-log-store/internal/config"
+	"simple-log-store/internal/logs"
+	"simple-log-store/internal/redis"
+	"simple-log-store/internal/storage"
+	"simple-log-store/internal/utils"
+)
+
+// Service computes indexes of log files after they have been committed and stores them
+// in redis, so they don't have to be computed on every request.
+type Service struct {
+	logger *slog.Logger
+
+	storageService *storage.Service
+	redisService   *redis.Service
+
+	timelines *timelineCache
+}
+
+func CreateService(appConfig *config.AppConfig, logger *slog.Logger, storageService *storage.Service, redisService *redis.Service) *Service {
+	return &Service{
+		logger:         logger.With(slog.String("service", "index")),
+		storageService: storageService,
+		redisService:   redisService,
+		timelines:      newTimelineCache(),
+	}
+}
+
+// IndexLogBundle indexes all log files of the log bundle and records the fingerprints of
+// their stack traces. Errors are logged.
+func (s *Service) IndexLogBundle(ctx context.Context, logBundleId logs.LogBundleId, logFileIds []logs.LogFileId) {
+	var stackTraces []logs.StackTrace
+	fingerprints := make(map[string]struct{})
+
+	for _, logFileId := range logFileIds {
+		if _, err := s.indexLogFile(ctx, logFileId); err != nil {
+			if errors.Is(err, errLogFileNotText) {
+				s.logger.Info("skipping index of binary log file", slog.String("logFileId", logFileId.String()))
+				continue
+			}
+
+			s.logger.Error("failed to index log file", slog.String("logFileId", logFileId.String()), utils.ErrAttr(err))
+			continue
+		}
+
+		fileStackTraces, err := s.redisService.GetLogFileStackTraces(ctx, logFileId)
+		if err != nil {
+			s.logger.Error("failed to get stack traces of log file", slog.String("logFileId", logFileId.String()), utils.ErrAttr(err))
+			continue
+		}
+
+		for _, stackTrace := range fileStackTraces {
+			if _, ok := fingerprints[stackTrace.Fingerprint]; ok {
+				continue
+			}
+
+			fingerprints[stackTrace.Fingerprint] = struct{}{}
+			stackTraces = append(stackTraces, stackTrace)
+		}
+	}
+
+	if err := s.redisService.AddSignatures(ctx, logBundleId, stackTraces); err != nil {
+		s.logger.Error("failed to add signatures of log bundle", slog.String("logBundleId", logBundleId.String()), utils.ErrAttr(err))
+	}
+}
+
+// GetLogFileEncoding returns the encoding of the log file that was recorded while staging it.
+// It's unknown for log files that were staged before encodings were detected.
+func (s *Service) GetLogFileEncoding(ctx context.Context, logFileId logs.LogFileId) (charset.Encoding, error) {
+	metadata, err := s.redisService.GetLogFileMetadata(ctx, logFileId)
+	if err != nil && !errors.Is(err, redis.ErrNotFound) {
+		return charset.Unknown, err
+	}
+
+	return metadata.Encoding, nil
+}
+
+// readLogFile calls read with the log file decoded to UTF-8 and the upper limit of the length
+// of its lines. Errors of read are wrapped with the action, errors while opening the log file
+// are returned unchanged.
+func (s *Service) readLogFile(logFileId logs.LogFileId, encoding charset.Encoding, action string, read func(reader io.Reader, maxLineLength int) error) error {
+	file, err := s.storageService.OpenDecodedLogFile(logFileId, encoding)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = file.Close()
+	}()
+
+	if err := read(file, file.MaxLineLength); err != nil {
+		return fmt.Errorf("failed to %s of log file `%s`: %w", action, logFileId.String(), err)
+	}
+
+	return nil
+}
+
+// errLogFileNotText is returned for binaries, which aren't indexed.
+var errLogFileNotText = errors.New("log file isn't text")
+
+func (s *Service) indexLogFile(ctx context.Context, logFileId logs.LogFileId) ([]logs.Level, error) {
+	storedMetadata, err := s.redisService.GetLogFileMetadata(ctx, logFileId)
+	if err != nil && !errors.Is(err, redis.ErrNotFound) {
+		return nil, err
+	}
+
+	if !storedMetadata.IsText() {
+		return nil, errLogFileNotText
+	}
+
+	encoding := storedMetadata.Encoding
+
+	// decoded log files can't seek, so every pass opens the log file again
+	var format logs.Format
+	err = s.readLogFile(logFileId, encoding, "detect format", func(reader io.Reader, maxLineLength int) (err error) {
+		format, err = DetectFormat(reader, maxLineLength)
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	var levels []logs.Level
+	var levelCounts map[logs.Level]uint64
+	err = s.readLogFile(logFileId, encoding, "classify lines", func(reader io.Reader, maxLineLength int) (err error) {
+		levels, levelCounts, err = ClassifyLines(reader, maxLineLength)
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	var stackTraces []logs.StackTrace
+	err = s.readLogFile(logFileId, encoding, "find stack traces", func(reader io.Reader, maxLineLength int) (err error) {
+		stackTraces, err = FindStackTraces(reader, maxLineLength)
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	// the offsets are used to seek in the original bytes, so the log file isn't decoded
+	var lineOffsets []int64
+	err = s.readLogFile(logFileId, charset.Unknown, "find line offsets", func(reader io.Reader, _ int) (err error) {
+		lineOffsets, err = FindLineOffsets(reader, encoding)
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := logs.LogFileMetadata{
+		LineCount:   uint64(len(levels)),
+		Format:      format,
+		LevelCounts: levelCounts,
+	}
+
+	if err := s.redisService.SetLogFileIndex(ctx, logFileId, levels, stackTraces, lineOffsets, metadata); err != nil {
+		return nil, err
+	}
+
+	return levels, nil
+}
+
+// GetLogFileLevels returns the level of every line of the log file. Log files that were
+// committed before levels were indexed are indexed on demand.
+func (s *Service) GetLogFileLevels(ctx context.Context, logFileId logs.LogFileId) ([]logs.Level, error) {
+	levels, err := s.redisService.GetLogFileLevels(ctx, logFileId)
+	if err == nil {
+		return levels, nil
+	}
+
+	if !errors.Is(err, redis.ErrNotFound) {
+		return nil, err
+	}
+
+	s.logger.Info("indexing log file on demand", slog.String("logFileId", logFileId.String()))
+	return s.indexLogFile(ctx, logFileId)
+}
+
+// GetLogFileStackTraces returns the stack traces of the log file. Log files that were committed
+// before stack traces were indexed are indexed on demand.
+func (s *Service) GetLogFileStackTraces(ctx context.Context, logFileId logs.LogFileId) ([]logs.StackTrace, error) {
+	stackTraces, err := s.redisService.GetLogFileStackTraces(ctx, logFileId)
+	if err == nil || !errors.Is(err, redis.ErrNotFound) {
+		return stackTraces, err
+	}
+
+	s.logger.Info("indexing log file on demand", slog.String("logFileId", logFileId.String()))
+	if _, err := s.indexLogFile(ctx, logFileId); err != nil {
+		if errors.Is(err, errLogFileNotText) {
+			// binaries don't have stack traces
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return s.redisService.GetLogFileStackTraces(ctx, logFileId)
+}
+
+// GetLogFilesStackTraces returns the stack traces of all log files with a single request to
+// redis. Log files that were committed before stack traces were indexed are indexed on demand,
+// the stack traces of log files that can't be indexed are nil and the errors are logged.
+func (s *Service) GetLogFilesStackTraces(ctx context.Context, logFileIds []logs.LogFileId) ([][]logs.StackTrace, error) {
+	stackTraces, err := s.redisService.GetLogFilesStackTraces(ctx, logFileIds)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, logFileId := range logFileIds {
+		if stackTraces[i] != nil {
+			continue
+		}
+
+		fileStackTraces, err := s.GetLogFileStackTraces(ctx, logFileId)
+		if err != nil {
+			s.logger.Warn("failed to get stack traces of log file", slog.String("logFileId", logFileId.String()), utils.ErrAttr(err))
+			continue
+		}
+
+		stackTraces[i] = fileStackTraces
+	}
+
+	return stackTraces, nil
+}
+
+// errInvalidLineNumber is returned for line number 0, line numbers start at 1.
+var errInvalidLineNumber = errors.New("invalid line number 0, line numbers start at 1")
+
+// GetLineOffset returns the byte offset of a line at or before the 1-based line number and the
+// number of lines between them. Log files that were committed before line offsets were indexed
+// are indexed on demand.
+func (s *Service) GetLineOffset(ctx context.Context, logFileId logs.LogFileId, lineNumber uint64) (int64, uint64, error) {
+	if lineNumber == 0 {
+		return 0, 0, errInvalidLineNumber
+	}
+
+	lineOffsets, err := s.redisService.GetLogFileLineOffsets(ctx, logFileId)
+	if errors.Is(err, redis.ErrNotFound) {
+		s.logger.Info("indexing log file on demand", slog.String("logFileId", logFileId.String()))
+		if _, err := s.indexLogFile(ctx, logFileId); err != nil {
+			return 0, 0, err
+		}
+
+		lineOffsets, err = s.redisService.GetLogFileLineOffsets(ctx, logFileId)
+	}
+
+	if err != nil {
+		return 0, 0, err
+	}
+
+	index := min(int((lineNumber-1)/LineOffsetInterval), len(lineOffsets)-1)
+	if index < 0 {
+		return 0, lineNumber - 1, nil
+	}
+
+	return lineOffsets[index], lineNumber - 1 - uint64(index)*LineOffsetInterval, nil
+}
+
+// GetLogFileMetadata returns the metadata of the log file. Log files that were committed
+// before the metadata was complete are indexed on demand, quarantined log files and binaries
+// are never indexed.
+func (s *Service) GetLogFileMetadata(ctx context.Context, logFileId logs.LogFileId) (logs.LogFileMetadata, error) {
+	metadata, err := s.redisService.GetLogFileMetadata(ctx, logFileId)
+	if err == nil && (metadata.Format != logs.FormatUnknown || metadata.Quarantine != "" || !metadata.IsText()) {
+		return metadata, nil
+	}
+
+	if err != nil && !errors.Is(err, redis.ErrNotFound) {
+		return metadata, err
+	}
+
+	s.logger.Info("indexing log file on demand", slog.String("logFileId", logFileId.String()))
+	if _, err := s.indexLogFile(ctx, logFileId); err != nil {
+		return metadata, err
+	}
+
+	return s.redisService.GetLogFileMetadata(ctx, logFileId)
+}
@@ -0,0 +1,275 @@
+package index
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"path"
+	"regexp"
+	"simple-log-store/internal/logs"
+	"strings"
+)
+
+// number of frames from the top of a stack trace that are part of the fingerprint
+const fingerprintFrames = 5
+
+// maximum length of the stored exception message
+const maxExceptionMessageLength = 200
+
+type stackTraceKind uint8
+
+const (
+	// .NET and Java: `   at Namespace.Class.Method()`
+	stackTraceKindJvm stackTraceKind = iota
+	// Python: `Traceback (most recent call last):`
+	stackTraceKindPython
+	// Go: `goroutine 1 [running]:`
+	stackTraceKindGo
+)
+
+var (
+	// a frame is a qualified method followed by its parameters, so indented prose starting
+	// with `at` isn't a frame. Java frames can start with a class loader and a module like
+	// `app//` or `java.base/`, .NET frames can contain generics and nested classes.
+	jvmFramePattern        = regexp.MustCompile(`^\s+at\s+((?:[\w$.@-]*/)*[\w$<>` + "`" + `\[\],.+|]+\.[\w$<>` + "`" + `\[\],|]+)\(`)
+	jvmContinuationPattern = regexp.MustCompile(`^(?:\s+\.\.\. \d+ (?:more|common frames omitted)|\s*--- End of |\s*---> |Caused by: )`)
+	pythonStartPattern     = regexp.MustCompile(`^Traceback \(most recent call last\):`)
+	pythonFramePattern     = regexp.MustCompile(`^\s+File "(.+?)", line \d+, in (.+)`)
+	goStartPattern         = regexp.MustCompile(`^goroutine \d+ \[.*\]:$`)
+	goFunctionPattern      = regexp.MustCompile(`^(?:created by )?([^\s(]+)\(.*\)(?: in goroutine \d+)?$|^created by (\S+)`)
+	goLocationPattern      = regexp.MustCompile(`^\t\S.*:\d+(?: \+0x[0-9a-f]+)?$`)
+
+	exceptionPattern       = regexp.MustCompile(`((?:[A-Za-z_][\w$]*\.)*[A-Za-z_][\w$]*(?:Exception|Error|Throwable|Fault))(?::\s*(.*))?`)
+	pythonExceptionPattern = regexp.MustCompile(`^([A-Za-z_][\w.]*)(?::\s*(.*))?$`)
+	goPanicPattern         = regexp.MustCompile(`^(panic|fatal error): (.*)`)
+
+	// numbers and addresses differ between builds and runs
+	frameNoisePattern = regexp.MustCompile(`0x[0-9a-fA-F]+|\d+`)
+)
+
+type stackTraceBuilder struct {
+	kind   stackTraceKind
+	trace  logs.StackTrace
+	header string
+	frames []string
+}
+
+// FindStackTraces returns all .NET, Java, Python and Go stack traces of the log file.
+func FindStackTraces(reader io.Reader, maxLineLength int) ([]logs.StackTrace, error) {
+	stackTraces := make([]logs.StackTrace, 0)
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), maxLineLength)
+
+	var current *stackTraceBuilder
+	finish := func() {
+		if current != nil && len(current.frames) != 0 {
+			stackTraces = append(stackTraces, current.build())
+		}
+
+		current = nil
+	}
+
+	// the exception is usually on the lines before the frames
+	var previousLines [2]string
+	lineNumber := uint64(0)
+
+	for scanner.Scan() {
+		lineNumber += 1
+		line := scanner.Text()
+
+		if current != nil && !current.add(lineNumber, line) {
+			finish()
+		}
+
+		if current == nil {
+			current = startStackTrace(lineNumber, line, previousLines)
+		}
+
+		previousLines[1] = previousLines[0]
+		previousLines[0] = line
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	finish()
+	return stackTraces, nil
+}
+
+func startStackTrace(lineNumber uint64, line string, previousLines [2]string) *stackTraceBuilder {
+	switch {
+	case jvmFramePattern.MatchString(line):
+		builder := &stackTraceBuilder{
+			kind: stackTraceKindJvm,
+			trace: logs.StackTrace{
+				FirstLine:  lineNumber,
+				FirstFrame: lineNumber,
+			},
+		}
+
+		if lineNumber > 1 && strings.TrimSpace(previousLines[0]) != "" {
+			builder.trace.FirstLine = lineNumber - 1
+			builder.header = previousLines[0]
+		}
+
+		builder.addJvmFrame(lineNumber, line)
+		return builder
+	case pythonStartPattern.MatchString(line):
+		return &stackTraceBuilder{
+			kind: stackTraceKindPython,
+			trace: logs.StackTrace{
+				FirstLine:  lineNumber,
+				FirstFrame: lineNumber + 1,
+			},
+		}
+	case goStartPattern.MatchString(line):
+		builder := &stackTraceBuilder{
+			kind: stackTraceKindGo,
+			trace: logs.StackTrace{
+				FirstLine:  lineNumber,
+				FirstFrame: lineNumber + 1,
+			},
+		}
+
+		// panic: message
+		//
+		// goroutine 1 [running]:
+		if goPanicPattern.MatchString(previousLines[1]) && strings.TrimSpace(previousLines[0]) == "" {
+			builder.trace.FirstLine = lineNumber - 2
+			builder.header = previousLines[1]
+		} else if goPanicPattern.MatchString(previousLines[0]) {
+			builder.trace.FirstLine = lineNumber - 1
+			builder.header = previousLines[0]
+		}
+
+		return builder
+	default:
+		return nil
+	}
+}
+
+// add adds the line to the stack trace and returns false if the line isn't part of it.
+func (b *stackTraceBuilder) add(lineNumber uint64, line string) bool {
+	switch b.kind {
+	case stackTraceKindJvm:
+		if jvmFramePattern.MatchString(line) {
+			b.addJvmFrame(lineNumber, line)
+			return true
+		}
+
+		if jvmContinuationPattern.MatchString(line) {
+			b.trace.LastFrame = lineNumber
+			b.trace.LastLine = lineNumber
+			return true
+		}
+	case stackTraceKindPython:
+		if b.header != "" {
+			return false
+		}
+
+		if match := pythonFramePattern.FindStringSubmatch(line); match != nil {
+			b.frames = append(b.frames, path.Base(strings.ReplaceAll(match[1], "\\", "/"))+":"+match[2])
+			b.trace.LastFrame = lineNumber
+			b.trace.LastLine = lineNumber
+			return true
+		}
+
+		// source code of the frame
+		if len(b.frames) != 0 && strings.HasPrefix(line, "    ") {
+			b.trace.LastFrame = lineNumber
+			b.trace.LastLine = lineNumber
+			return true
+		}
+
+		// the exception is the last line of the stack trace
+		if len(b.frames) != 0 && b.header == "" && strings.TrimSpace(line) != "" {
+			b.header = line
+			b.trace.LastLine = lineNumber
+			return true
+		}
+	case stackTraceKindGo:
+		if match := goFunctionPattern.FindStringSubmatch(line); match != nil {
+			if !strings.HasPrefix(line, "created by ") {
+				b.frames = append(b.frames, match[1])
+			}
+
+			b.trace.LastFrame = lineNumber
+			b.trace.LastLine = lineNumber
+			return true
+		}
+
+		if goLocationPattern.MatchString(line) {
+			b.trace.LastFrame = lineNumber
+			b.trace.LastLine = lineNumber
+			return true
+		}
+	}
+
+	return false
+}
+
+func (b *stackTraceBuilder) addJvmFrame(lineNumber uint64, line string) {
+	if match := jvmFramePattern.FindStringSubmatch(line); match != nil {
+		b.frames = append(b.frames, match[1])
+	}
+
+	b.trace.LastFrame = lineNumber
+	b.trace.LastLine = lineNumber
+}
+
+func (b *stackTraceBuilder) build() logs.StackTrace {
+	trace := b.trace
+	header := strings.TrimSpace(b.header)
+
+	switch b.kind {
+	case stackTraceKindJvm:
+		if match := exceptionPattern.FindStringSubmatch(header); match != nil {
+			trace.Exception = match[1]
+			trace.Message = match[2]
+		} else {
+			trace.Message = header
+		}
+	case stackTraceKindPython:
+		if match := pythonExceptionPattern.FindStringSubmatch(header); match != nil {
+			trace.Exception = match[1]
+			trace.Message = match[2]
+		} else {
+			trace.Message = header
+		}
+	case stackTraceKindGo:
+		if match := goPanicPattern.FindStringSubmatch(header); match != nil {
+			trace.Exception = match[1]
+			trace.Message = match[2]
+		} else {
+			trace.Exception = "panic"
+		}
+	}
+
+	if len(trace.Message) > maxExceptionMessageLength {
+		trace.Message = strings.ToValidUTF8(trace.Message[:maxExceptionMessageLength], "")
+	}
+
+	trace.Fingerprint = fingerprint(trace.Exception, b.frames)
+	return trace
+}
+
+// fingerprint hashes the exception type and the top frames without line numbers, arguments
+// and addresses, so the same exception thrown at the same location has the same fingerprint.
+func fingerprint(exception string, frames []string) string {
+	hash := sha256.New()
+	hash.Write([]byte(exception))
+
+	for i, frame := range frames {
+		if i == fingerprintFrames {
+			break
+		}
+
+		hash.Write([]byte{'\n'})
+		hash.Write([]byte(frameNoisePattern.ReplaceAllLiteralString(frame, "N")))
+	}
+
+	return hex.EncodeToString(hash.Sum(nil))[:16]
+}
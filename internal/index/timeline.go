@@ -0,0 +1,165 @@
+package index
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"simple-log-store/internal/logs"
+	"simple-log-store/internal/redis"
+	"simple-log-store/internal/utils"
+	"sort"
+	"sync"
+)
+
+// MergedTimelineEntry is an entry of a log file in a merged timeline.
+type MergedTimelineEntry struct {
+	TimelineEntry
+	// FileIndex is the index of the log file in the list of log files of the timeline.
+	FileIndex int
+}
+
+// Timeline contains the entries of multiple log files in chronological order.
+type Timeline struct {
+	Entries []MergedTimelineEntry
+	// FilesWithoutTimestamps contains the indices of log files without any timestamps,
+	// these files aren't part of the timeline.
+	FilesWithoutTimestamps []int
+	// UnavailableFiles contains the indices of log files that couldn't be read, because they
+	// are still being committed, were quarantined or are missing.
+	UnavailableFiles []int
+}
+
+// BuildTimeline parses the timestamps of all log files and merges their entries in
+// chronological order. Entries with the same time keep the order of the log files. Log files
+// that can't be read are skipped, so the timeline of the other log files is still usable.
+func (s *Service) BuildTimeline(ctx context.Context, logFileIds []logs.LogFileId) (Timeline, error) {
+	var timeline Timeline
+
+	for i, logFileId := range logFileIds {
+		entries, err := s.readTimelineEntries(ctx, logFileId)
+		if err != nil {
+			if !errors.Is(err, errLogFileUnavailable) {
+				s.logger.Error("failed to read timeline of log file", slog.String("logFileId", logFileId.String()), utils.ErrAttr(err))
+			}
+
+			timeline.UnavailableFiles = append(timeline.UnavailableFiles, i)
+			continue
+		}
+
+		if len(entries) == 0 {
+			timeline.FilesWithoutTimestamps = append(timeline.FilesWithoutTimestamps, i)
+			continue
+		}
+
+		for _, entry := range entries {
+			timeline.Entries = append(timeline.Entries, MergedTimelineEntry{
+				TimelineEntry: entry,
+				FileIndex:     i,
+			})
+		}
+	}
+
+	sort.SliceStable(timeline.Entries, func(i, j int) bool {
+		return timeline.Entries[i].Time.Before(timeline.Entries[j].Time)
+	})
+
+	return timeline, nil
+}
+
+// errLogFileUnavailable is returned for log files that aren't committed, were quarantined or are missing.
+var errLogFileUnavailable = errors.New("log file is unavailable")
+
+// readTimelineEntries returns the cached entries of the log file or parses them. Committed log
+// files never change, so their entries are cached until they are evicted.
+func (s *Service) readTimelineEntries(ctx context.Context, logFileId logs.LogFileId) ([]TimelineEntry, error) {
+	if entries, ok := s.timelines.get(logFileId); ok {
+		return entries, nil
+	}
+
+	metadata, err := s.redisService.GetLogFileMetadata(ctx, logFileId)
+	if err != nil && !errors.Is(err, redis.ErrNotFound) {
+		return nil, err
+	}
+
+	if metadata.Quarantine != "" {
+		return nil, errLogFileUnavailable
+	}
+
+	// binaries don't have timestamps
+	if !metadata.IsText() {
+		return nil, nil
+	}
+
+	var entries []TimelineEntry
+	err = s.readLogFile(logFileId, metadata.Encoding, "read timestamps", func(reader io.Reader, maxLineLength int) (err error) {
+		entries, err = ReadTimelineEntries(reader, maxLineLength)
+		return err
+	})
+
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, errLogFileUnavailable
+		}
+
+		return nil, err
+	}
+
+	s.timelines.add(logFileId, entries)
+	return entries, nil
+}
+
+// maximum number of timeline entries kept in memory, about 40 bytes each
+const maxCachedTimelineEntries = 500_000
+
+// maximum number of log files in the timeline cache, including log files without entries
+const maxCachedTimelineFiles = 10_000
+
+// timelineCache keeps the entries of the most recently parsed log files, so the pages of a
+// timeline don't parse all log files of the bundle again. The oldest log files are evicted
+// first once the cache holds more than maxCachedTimelineEntries entries or
+// maxCachedTimelineFiles log files.
+type timelineCache struct {
+	mutex      sync.Mutex
+	entries    map[logs.LogFileId][]TimelineEntry
+	order      []logs.LogFileId
+	entryCount int
+}
+
+func newTimelineCache() *timelineCache {
+	return &timelineCache{entries: make(map[logs.LogFileId][]TimelineEntry)}
+}
+
+func (c *timelineCache) get(logFileId logs.LogFileId) ([]TimelineEntry, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	entries, ok := c.entries[logFileId]
+	return entries, ok
+}
+
+// add caches the entries, unless a single log file has more entries than the cache can hold.
+func (c *timelineCache) add(logFileId logs.LogFileId, entries []TimelineEntry) {
+	if len(entries) > maxCachedTimelineEntries {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if _, ok := c.entries[logFileId]; ok {
+		return
+	}
+
+	for c.entryCount+len(entries) > maxCachedTimelineEntries || len(c.order) >= maxCachedTimelineFiles {
+		evicted := c.order[0]
+		c.order = c.order[1:]
+		c.entryCount -= len(c.entries[evicted])
+		delete(c.entries, evicted)
+	}
+
+	c.entries[logFileId] = entries
+	c.order = append(c.order, logFileId)
+	c.entryCount += len(entries)
+}
@@ -0,0 +1,138 @@
+package index
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// only the beginning of a line is searched for timestamps
+const timestampSearchLength = 256
+
+var (
+	// ISO 8601 and similar: 2024-01-01T12:00:00.123+02:00, 2024-01-01 12:00:00,123, 2024/01/01 12:00:00 UTC
+	dateTimePattern = regexp.MustCompile(`(\d{4})[-/](\d{2})[-/](\d{2})[T ](\d{2}):(\d{2}):(\d{2})(?:[.,](\d{1,9}))?\s?(Z|UTC|[+-]\d{2}:?\d{2})?`)
+	// Unix timestamps in JSON lines: {"ts":1704110400.123}, {"time":1704110400123}
+	epochPattern = regexp.MustCompile(`"(?:ts|time|timestamp|@t)"\s*:\s*(\d{10})(?:(\d{3})|\.(\d{1,9}))?\b`)
+)
+
+// ParseTimestamp returns the first timestamp at the beginning of the line. Timestamps without
+// a timezone offset are treated as UTC.
+func ParseTimestamp(line []byte) (time.Time, bool) {
+	if len(line) > timestampSearchLength {
+		line = line[:timestampSearchLength]
+	}
+
+	if match := dateTimePattern.FindSubmatch(line); match != nil {
+		return parseDateTime(match)
+	}
+
+	if match := epochPattern.FindSubmatch(line); match != nil {
+		seconds, _ := strconv.ParseInt(string(match[1]), 10, 64)
+		nanoseconds := 0
+		if len(match[2]) != 0 {
+			nanoseconds = atoi(match[2]) * int(time.Millisecond)
+		} else if len(match[3]) != 0 {
+			nanoseconds = parseFraction(match[3])
+		}
+
+		return time.Unix(seconds, int64(nanoseconds)).UTC(), true
+	}
+
+	return time.Time{}, false
+}
+
+func parseDateTime(match [][]byte) (time.Time, bool) {
+	year, month, day := atoi(match[1]), atoi(match[2]), atoi(match[3])
+	hour, minute, second := atoi(match[4]), atoi(match[5]), atoi(match[6])
+	if month < 1 || month > 12 || day < 1 || day > 31 || hour > 23 || minute > 59 || second > 60 {
+		return time.Time{}, false
+	}
+
+	location := time.UTC
+	if zone := match[8]; len(zone) != 0 && zone[0] != 'Z' && zone[0] != 'U' {
+		offsetHours := atoi(zone[1:3])
+		offsetMinutes := atoi(zone[len(zone)-2:])
+		offset := offsetHours*60*60 + offsetMinutes*60
+		if zone[0] == '-' {
+			offset = -offset
+		}
+
+		location = time.FixedZone("", offset)
+	}
+
+	timestamp := time.Date(year, time.Month(month), day, hour, minute, second, parseFraction(match[7]), location)
+	return timestamp.UTC(), true
+}
+
+// parseFraction converts the digits after the decimal point into nanoseconds.
+func parseFraction(digits []byte) int {
+	nanoseconds := 0
+	for i := 0; i < 9; i++ {
+		nanoseconds *= 10
+		if i < len(digits) {
+			nanoseconds += int(digits[i] - '0')
+		}
+	}
+
+	return nanoseconds
+}
+
+func atoi(digits []byte) int {
+	res := 0
+	for _, digit := range digits {
+		res = res*10 + int(digit-'0')
+	}
+
+	return res
+}
+
+// TimelineEntry is a log entry that starts with a timestamp and contains all following lines
+// without a timestamp, like stack traces.
+type TimelineEntry struct {
+	// FirstLine is the 1-based line number of the first line of the entry.
+	FirstLine uint64
+	LineCount uint64
+	Time      time.Time
+}
+
+// ReadTimelineEntries splits the lines into entries. Lines before the first timestamp are
+// part of an entry with the time of the first timestamp. No entries are returned if the
+// reader doesn't contain any timestamps.
+func ReadTimelineEntries(reader io.Reader, maxLineLength int) ([]TimelineEntry, error) {
+	var entries []TimelineEntry
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), maxLineLength)
+
+	lineNumber := uint64(0)
+	leadingLines := uint64(0)
+	for scanner.Scan() {
+		lineNumber += 1
+
+		timestamp, ok := ParseTimestamp(scanner.Bytes())
+		if !ok {
+			if len(entries) == 0 {
+				leadingLines += 1
+			} else {
+				entries[len(entries)-1].LineCount += 1
+			}
+
+			continue
+		}
+
+		if len(entries) == 0 && leadingLines != 0 {
+			entries = append(entries, TimelineEntry{FirstLine: 1, LineCount: leadingLines, Time: timestamp})
+		}
+
+		entries = append(entries, TimelineEntry{FirstLine: lineNumber, LineCount: 1, Time: timestamp})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,157 @@
+package ingest
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"slices"
+)
+
+// BuiltinHookNames returns the names of all hooks that don't need an external scanner.
+func BuiltinHookNames() []string {
+	return []string{"magic", "text"}
+}
+
+// GetBuiltinHooks returns the built-in hooks with the names, in the same order. The name
+// `none` is ignored, so all built-in hooks can be disabled.
+func GetBuiltinHooks(names []string) ([]Hook, error) {
+	var res []Hook
+	for _, name := range names {
+		switch name {
+		case "none":
+		case "magic":
+			res = append(res, MagicHook{})
+		case "text":
+			res = append(res, TextHook{})
+		default:
+			return nil, fmt.Errorf("unknown ingestion hook `%s`, expected one of %v", name, BuiltinHookNames())
+		}
+	}
+
+	return res, nil
+}
+
+type signature struct {
+	name   string
+	offset int
+	magic  []byte
+	// valid checks the rest of the header of short signatures, which also occur in text
+	valid func(head []byte) bool
+}
+
+// signatures of common binaries and archives, which are never log files
+var signatures = []signature{
+	{name: "ELF executable", magic: []byte("\x7fELF")},
+	{name: "Windows executable", magic: []byte("MZ"), valid: isPortableExecutable},
+	{name: "Mach-O executable", magic: []byte("\xcf\xfa\xed\xfe")},
+	{name: "Mach-O executable", magic: []byte("\xce\xfa\xed\xfe")},
+	{name: "Mach-O universal binary", magic: []byte("\xca\xfe\xba\xbe")},
+	{name: "zip archive", magic: []byte("PK\x03\x04")},
+	{name: "zip archive", magic: []byte("PK\x05\x06")},
+	{name: "gzip archive", magic: []byte("\x1f\x8b")},
+	{name: "bzip2 archive", magic: []byte("BZh"), valid: isBzip2},
+	{name: "xz archive", magic: []byte("\xfd7zXZ\x00")},
+	{name: "zstd archive", magic: []byte("\x28\xb5\x2f\xfd")},
+	{name: "7z archive", magic: []byte("7z\xbc\xaf\x27\x1c")},
+	{name: "rar archive", magic: []byte("Rar!\x1a\x07")},
+	{name: "tar archive", offset: 257, magic: []byte("ustar")},
+	{name: "PDF document", magic: []byte("%PDF-")},
+	{name: "PNG image", magic: []byte("\x89PNG\r\n\x1a\n")},
+	{name: "JPEG image", magic: []byte("\xff\xd8\xff")},
+	{name: "GIF image", magic: []byte("GIF87a")},
+	{name: "GIF image", magic: []byte("GIF89a")},
+	{name: "SQLite database", magic: []byte("SQLite format 3\x00")},
+}
+
+// MagicHook rejects log files that start with the signature of a binary or an archive.
+type MagicHook struct{}
+
+func (MagicHook) Name() string {
+	return "magic"
+}
+
+func (MagicHook) Inspect(_ context.Context, file StagedFile) (Verdict, error) {
+	for _, signature := range signatures {
+		end := signature.offset + len(signature.magic)
+		if len(file.Head) < end || !bytes.Equal(file.Head[signature.offset:end], signature.magic) {
+			continue
+		}
+
+		if signature.valid == nil || signature.valid(file.Head) {
+			return Reject("looks like a " + signature.name), nil
+		}
+	}
+
+	return Accept(), nil
+}
+
+// isPortableExecutable returns true if the DOS header points to the `PE\0\0` signature of
+// the Windows header, which is where 32 and 64 bit executables and DLLs start.
+func isPortableExecutable(head []byte) bool {
+	const headerOffset = 0x3c
+	if len(head) < headerOffset+4 {
+		return false
+	}
+
+	offset := int(binary.LittleEndian.Uint32(head[headerOffset:]))
+	return offset >= headerOffset+4 && offset <= len(head)-4 && bytes.Equal(head[offset:offset+4], []byte("PE\x00\x00"))
+}
+
+// isBzip2 returns true if the block size and the magic number of the first block, or of the
+// end of the stream for empty archives, follow the `BZh` signature.
+func isBzip2(head []byte) bool {
+	if len(head) < 10 || head[3] < '1' || head[3] > '9' {
+		return false
+	}
+
+	return bytes.Equal(head[4:10], []byte("\x31\x41\x59\x26\x53\x59")) || bytes.Equal(head[4:10], []byte("\x17\x72\x45\x38\x50\x90"))
+}
+
+// maximum share of control characters in a text file
+const maxControlRatio = 0.1
+
+// byte order marks of UTF-16, which is text even though it contains null bytes
+var utf16ByteOrderMarks = [][]byte{{0xff, 0xfe}, {0xfe, 0xff}}
+
+// TextHook rejects log files that contain null bytes or too many control characters.
+// Bytes that aren't valid UTF-8 are allowed because logs are often written in legacy encodings.
+type TextHook struct{}
+
+func (TextHook) Name() string {
+	return "text"
+}
+
+func (TextHook) Inspect(_ context.Context, file StagedFile) (Verdict, error) {
+	if slices.ContainsFunc(utf16ByteOrderMarks, func(bom []byte) bool { return bytes.HasPrefix(file.Head, bom) }) {
+		return Accept(), nil
+	}
+
+	if bytes.IndexByte(file.Head, 0) != -1 {
+		return Reject("contains null bytes"), nil
+	}
+
+	controlCount := 0
+	for _, b := range file.Head {
+		if isControl(b) {
+			controlCount += 1
+		}
+	}
+
+	if len(file.Head) != 0 && float64(controlCount)/float64(len(file.Head)) > maxControlRatio {
+		return Reject("contains too many control characters"), nil
+	}
+
+	return Accept(), nil
+}
+
+// isControl returns true for control characters that aren't whitespace or the escape
+// character, which starts the ANSI escape sequences of colored logs.
+func isControl(b byte) bool {
+	switch b {
+	case '\t', '\n', '\v', '\f', '\r', 0x1b:
+		return false
+	default:
+		return b < 0x20 || b == 0x7f
+	}
+}
@@ -0,0 +1,68 @@
+package ingest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"slices"
+	"strings"
+)
+
+// maximum length of the output of a scanner used as the reason of a rejection
+const maxReasonLength = 200
+
+// ExecScanner runs a command with its arguments and the path of the staged log file as the
+// last argument. Like ClamAV's `clamscan`, the exit code 0 accepts the log file, 1 rejects it
+// with the first line of the output as the reason and any other exit code is an error.
+type ExecScanner struct {
+	command string
+	args    []string
+}
+
+func NewExecScanner(command string, args []string) *ExecScanner {
+	return &ExecScanner{command: command, args: args}
+}
+
+func (s *ExecScanner) Name() string {
+	return "exec"
+}
+
+func (s *ExecScanner) Inspect(ctx context.Context, file StagedFile) (Verdict, error) {
+	args := append(slices.Clone(s.args), file.Path)
+	cmd := exec.CommandContext(ctx, s.command, args...)
+
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	err := cmd.Run()
+	if err == nil {
+		return Accept(), nil
+	}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 && ctx.Err() == nil {
+		// scanners like clamscan prefix the result with the path, which is meaningless to users
+		reason := strings.ReplaceAll(output.String(), file.Path+": ", "")
+		return Reject(getReason(reason, "rejected by scanner")), nil
+	}
+
+	return Verdict{}, fmt.Errorf("failed to run scanner `%s`: %w: %s", s.command, err, getReason(output.String(), "no output"))
+}
+
+// getReason returns the first line of the output, shortened to maxReasonLength.
+func getReason(output string, fallback string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(output), "\n")
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return fallback
+	}
+
+	if runes := []rune(line); len(runes) > maxReasonLength {
+		return string(runes[:maxReasonLength])
+	}
+
+	return line
+}
@@ -0,0 +1,143 @@
+package ingest
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const defaultIcapPort = "1344"
+
+// size of the chunks of the log file sent to the ICAP server
+const icapChunkSize = 32 * 1024
+
+// IcapScanner sends the staged log file to an ICAP server (RFC 3507) like c-icap with
+// ClamAV, as the body of an HTTP response in a RESPMOD request. The server accepts the
+// log file with `204 No Content` and rejects it by modifying the response, usually with
+// an `X-Infection-Found` or `X-Violations-Found` header describing the threat.
+type IcapScanner struct {
+	serviceUrl *url.URL
+}
+
+func NewIcapScanner(serviceUrl string) (*IcapScanner, error) {
+	parsed, err := url.Parse(serviceUrl)
+	if err != nil || parsed.Scheme != "icap" || parsed.Host == "" {
+		return nil, fmt.Errorf("invalid ICAP URL `%s`, expected a URL like `icap://localhost:1344/avscan`", serviceUrl)
+	}
+
+	return &IcapScanner{serviceUrl: parsed}, nil
+}
+
+func (s *IcapScanner) Name() string {
+	return "icap"
+}
+
+func (s *IcapScanner) Inspect(ctx context.Context, file StagedFile) (Verdict, error) {
+	address := s.serviceUrl.Host
+	if s.serviceUrl.Port() == "" {
+		address = net.JoinHostPort(s.serviceUrl.Hostname(), defaultIcapPort)
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return Verdict{}, fmt.Errorf("failed to connect to ICAP server: %w", err)
+	}
+
+	defer func(conn net.Conn) {
+		_ = conn.Close()
+	}(conn)
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	if err := s.writeRequest(conn, file.Path); err != nil {
+		return Verdict{}, err
+	}
+
+	reader := textproto.NewReader(bufio.NewReader(conn))
+	statusLine, err := reader.ReadLine()
+	if err != nil {
+		return Verdict{}, fmt.Errorf("failed to read ICAP response: %w", err)
+	}
+
+	header, err := reader.ReadMIMEHeader()
+	if err != nil {
+		return Verdict{}, fmt.Errorf("failed to read ICAP response headers: %w", err)
+	}
+
+	// status lines look like `ICAP/1.0 204 No Content`
+	fields := strings.Fields(statusLine)
+	if len(fields) < 2 || !strings.HasPrefix(fields[0], "ICAP/") {
+		return Verdict{}, fmt.Errorf("invalid ICAP status line `%s`", statusLine)
+	}
+
+	switch fields[1] {
+	case "204":
+		return Accept(), nil
+	case "200":
+		for _, key := range []string{"X-Infection-Found", "X-Violations-Found", "X-Virus-Id"} {
+			if value := header.Get(key); value != "" {
+				return Reject(getReason(value, "")), nil
+			}
+		}
+
+		return Reject("modified by the ICAP server"), nil
+	default:
+		return Verdict{}, fmt.Errorf("unexpected ICAP status `%s`", strings.Join(fields[1:], " "))
+	}
+}
+
+// writeRequest writes a RESPMOD request with the log file as the chunked response body.
+func (s *IcapScanner) writeRequest(conn net.Conn, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open staged log file: %w", err)
+	}
+
+	defer func(file *os.File) {
+		_ = file.Close()
+	}(file)
+
+	responseHeader := "HTTP/1.1 200 OK\r\nContent-Type: text/plain\r\n\r\n"
+
+	writer := bufio.NewWriter(conn)
+	_, _ = fmt.Fprintf(writer, "RESPMOD %s ICAP/1.0\r\n", s.serviceUrl.String())
+	_, _ = fmt.Fprintf(writer, "Host: %s\r\n", s.serviceUrl.Host)
+	_, _ = fmt.Fprintf(writer, "Allow: 204\r\n")
+	_, _ = fmt.Fprintf(writer, "Encapsulated: res-hdr=0, res-body=%d\r\n\r\n", len(responseHeader))
+	_, _ = writer.WriteString(responseHeader)
+
+	buffer := make([]byte, icapChunkSize)
+	for {
+		n, err := file.Read(buffer)
+		if n > 0 {
+			_, _ = writer.WriteString(strconv.FormatInt(int64(n), 16) + "\r\n")
+			_, _ = writer.Write(buffer[:n])
+			_, _ = writer.WriteString("\r\n")
+		}
+
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return fmt.Errorf("failed to read staged log file: %w", err)
+		}
+	}
+
+	_, _ = writer.WriteString("0\r\n\r\n")
+	if err := writer.Flush(); err != nil {
+		return fmt.Errorf("failed to send ICAP request: %w", err)
+	}
+
+	return nil
+}
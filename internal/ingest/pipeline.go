@@ -0,0 +1,146 @@
+// Package ingest inspects staged log files before they are committed, to keep files that
+// aren't logs or that are flagged by a scanner out of the storage.
+package ingest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"simple-log-store/internal/config"
+	"time"
+)
+
+// HeadSize is the maximum number of bytes at the start of the log file passed to the hooks.
+const HeadSize = 8 * 1024
+
+// StagedFile is a log file in the staging directory.
+type StagedFile struct {
+	Path string
+	// Head contains the first bytes of the log file, up to HeadSize.
+	Head []byte
+}
+
+// Verdict is the decision of a hook. The reason is only set if the log file is rejected.
+type Verdict struct {
+	Rejected bool
+	Reason   string
+}
+
+func Accept() Verdict {
+	return Verdict{}
+}
+
+func Reject(reason string) Verdict {
+	return Verdict{Rejected: true, Reason: reason}
+}
+
+// Hook inspects a staged log file before it's committed. An error means that the log file
+// couldn't be inspected, like when a scanner is unavailable, and not that it was rejected.
+type Hook interface {
+	Name() string
+	Inspect(ctx context.Context, file StagedFile) (Verdict, error)
+}
+
+// Result is the outcome of all hooks of a pipeline.
+type Result struct {
+	Rejected bool
+	// Hook is the name of the hook that rejected the log file.
+	Hook   string
+	Reason string
+}
+
+func (r Result) String() string {
+	return r.Hook + ": " + r.Reason
+}
+
+// Pipeline runs hooks in order until one of them rejects the log file.
+type Pipeline struct {
+	hooks   []Hook
+	timeout time.Duration
+}
+
+func NewPipeline(hooks []Hook, timeout time.Duration) *Pipeline {
+	return &Pipeline{hooks: hooks, timeout: timeout}
+}
+
+// CreatePipeline creates the pipeline of the built-in hooks and the external scanners that
+// are configured. It returns nil if no hooks are enabled.
+func CreatePipeline(appConfig *config.AppConfig) (*Pipeline, error) {
+	hooks, err := GetBuiltinHooks(appConfig.IngestHooks)
+	if err != nil {
+		return nil, err
+	}
+
+	if appConfig.ScanCommand != "" {
+		hooks = append(hooks, NewExecScanner(appConfig.ScanCommand, appConfig.ScanCommandArgs))
+	}
+
+	if appConfig.ScanIcapUrl != "" {
+		scanner, err := NewIcapScanner(appConfig.ScanIcapUrl)
+		if err != nil {
+			return nil, err
+		}
+
+		hooks = append(hooks, scanner)
+	}
+
+	if len(hooks) == 0 {
+		return nil, nil
+	}
+
+	return NewPipeline(hooks, appConfig.ScanTimeout), nil
+}
+
+// Inspect runs the hooks on the staged log file. Errors of a hook stop the pipeline, so
+// the log file is neither committed nor rejected.
+func (p *Pipeline) Inspect(ctx context.Context, path string) (Result, error) {
+	head, err := readHead(path)
+	if err != nil {
+		return Result{}, err
+	}
+
+	file := StagedFile{Path: path, Head: head}
+	for _, hook := range p.hooks {
+		verdict, err := p.inspect(ctx, hook, file)
+		if err != nil {
+			return Result{}, fmt.Errorf("ingestion hook `%s` failed: %w", hook.Name(), err)
+		}
+
+		if verdict.Rejected {
+			return Result{Rejected: true, Hook: hook.Name(), Reason: verdict.Reason}, nil
+		}
+	}
+
+	return Result{}, nil
+}
+
+func (p *Pipeline) inspect(ctx context.Context, hook Hook, file StagedFile) (Verdict, error) {
+	if p.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.timeout)
+		defer cancel()
+	}
+
+	return hook.Inspect(ctx, file)
+}
+
+func readHead(path string) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open staged log file: %w", err)
+	}
+
+	defer func(file *os.File) {
+		_ = file.Close()
+	}(file)
+
+	head := make([]byte, HeadSize)
+	n, err := io.ReadFull(file, head)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, fmt.Errorf("failed to read staged log file: %w", err)
+	}
+
+	return head[:n], nil
+}
@@ -0,0 +1,154 @@
+// Package jsonlines parses log files with one JSON object per line (NDJSON).
+package jsonlines
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+// Entry is a single JSON object of a log file.
+type Entry map[string]any
+
+// Parse parses the line as a JSON object, numbers are kept as json.Number.
+func Parse(line []byte) (Entry, bool) {
+	line = bytes.TrimSpace(line)
+	if len(line) == 0 || line[0] != '{' {
+		return nil, false
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(line))
+	decoder.UseNumber()
+
+	var entry Entry
+	if err := decoder.Decode(&entry); err != nil {
+		return nil, false
+	}
+
+	// trailing data after the object
+	if decoder.More() {
+		return nil, false
+	}
+
+	return entry, true
+}
+
+// Get returns the value of the field. Nested fields are separated by dots, like `http.status`,
+// but a field with a dot in its name takes precedence.
+func (e Entry) Get(key string) (any, bool) {
+	if value, ok := e[key]; ok {
+		return value, true
+	}
+
+	head, tail, found := strings.Cut(key, ".")
+	if !found {
+		return nil, false
+	}
+
+	nested, ok := e[head].(map[string]any)
+	if !ok {
+		return nil, false
+	}
+
+	return Entry(nested).Get(tail)
+}
+
+// GetString returns the formatted value of the field or an empty string if the field doesn't exist.
+func (e Entry) GetString(key string) string {
+	value, ok := e.Get(key)
+	if !ok {
+		return ""
+	}
+
+	return FormatValue(value)
+}
+
+// Keys returns the top-level field names in alphabetical order.
+func (e Entry) Keys() []string {
+	keys := make([]string, 0, len(e))
+	for key := range e {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
+
+// FormatValue formats a JSON value for display. Strings are returned without quotes,
+// objects and arrays as compact JSON.
+func FormatValue(value any) string {
+	switch value := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return value
+	case json.Number:
+		return value.String()
+	case bool:
+		if value {
+			return "true"
+		}
+
+		return "false"
+	default:
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return ""
+		}
+
+		return string(encoded)
+	}
+}
+
+// common field names of timestamps, levels and messages, in order of preference
+var (
+	timestampKeys = []string{"time", "timestamp", "ts", "@t", "@timestamp", "date", "datetime", "asctime"}
+	levelKeys     = []string{"level", "lvl", "severity", "@l", "levelname", "loglevel"}
+	messageKeys   = []string{"msg", "message", "@m", "@mt", "text"}
+)
+
+func findKey(entry Entry, candidates []string) (string, bool) {
+	for _, key := range candidates {
+		if _, ok := entry[key]; ok {
+			return key, true
+		}
+	}
+
+	return "", false
+}
+
+// DefaultColumns returns the timestamp, level and message fields used by the entries.
+func DefaultColumns(entries []Entry) []string {
+	var columns []string
+	for _, candidates := range [][]string{timestampKeys, levelKeys, messageKeys} {
+		for _, entry := range entries {
+			if key, ok := findKey(entry, candidates); ok {
+				columns = append(columns, key)
+				break
+			}
+		}
+	}
+
+	return columns
+}
+
+// AllKeys returns the top-level field names of all entries in alphabetical order.
+func AllKeys(entries []Entry) []string {
+	seen := make(map[string]struct{})
+	var keys []string
+
+	for _, entry := range entries {
+		for key := range entry {
+			if _, ok := seen[key]; ok {
+				continue
+			}
+
+			seen[key] = struct{}{}
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,113 @@
+package jsonlines
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Filter matches entries by the value of a field, like `level=error` or `user_id!=42`.
+type Filter struct {
+	Key   string
+	Value string
+	// Negated filters match entries where the field has a different value or doesn't exist.
+	Negated bool
+}
+
+func (f Filter) String() string {
+	operator := "="
+	if f.Negated {
+		operator = "!="
+	}
+
+	value := f.Value
+	if strings.ContainsAny(value, " \t\"") {
+		value = fmt.Sprintf("%q", value)
+	}
+
+	return f.Key + operator + value
+}
+
+// Matches compares the formatted value of the field, ignoring case.
+func (f Filter) Matches(entry Entry) bool {
+	value, ok := entry.Get(f.Key)
+	matches := ok && strings.EqualFold(FormatValue(value), f.Value)
+	return matches != f.Negated
+}
+
+// MatchesAll returns true if the entry matches every filter.
+func MatchesAll(entry Entry, filters []Filter) bool {
+	for _, filter := range filters {
+		if !filter.Matches(entry) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ParseFilters parses whitespace separated filters like `level=error user_id=42`. Values
+// with whitespace can be quoted: `msg="connection refused"`.
+func ParseFilters(input string) ([]Filter, error) {
+	terms, err := splitTerms(input)
+	if err != nil {
+		return nil, err
+	}
+
+	filters := make([]Filter, 0, len(terms))
+	for _, term := range terms {
+		key, value, found := strings.Cut(term, "=")
+		if !found || key == "" || key == "!" {
+			return nil, fmt.Errorf("invalid filter `%s`, expected `key=value`", term)
+		}
+
+		filter := Filter{Key: key, Value: value}
+		if strings.HasSuffix(key, "!") {
+			filter.Key = strings.TrimSuffix(key, "!")
+			filter.Negated = true
+		}
+
+		filters = append(filters, filter)
+	}
+
+	return filters, nil
+}
+
+var errUnterminatedQuote = errors.New("unterminated quote in filter")
+
+func splitTerms(input string) ([]string, error) {
+	var terms []string
+	var builder strings.Builder
+	inQuotes, inTerm := false, false
+
+	for i := 0; i < len(input); i++ {
+		c := input[i]
+		switch {
+		case c == '"':
+			inQuotes = !inQuotes
+			inTerm = true
+		case c == '\\' && inQuotes && i+1 < len(input):
+			i += 1
+			builder.WriteByte(input[i])
+		case (c == ' ' || c == '\t') && !inQuotes:
+			if inTerm {
+				terms = append(terms, builder.String())
+				builder.Reset()
+				inTerm = false
+			}
+		default:
+			builder.WriteByte(c)
+			inTerm = true
+		}
+	}
+
+	if inQuotes {
+		return nil, errUnterminatedQuote
+	}
+
+	if inTerm {
+		terms = append(terms, builder.String())
+	}
+
+	return terms, nil
+}
@@ -0,0 +1,145 @@
+package jsonlines
+
+import "testing"
+
+func TestParseFilters(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []Filter
+	}{
+		{
+			name:     "single filter",
+			input:    "level=error",
+			expected: []Filter{{Key: "level", Value: "error"}},
+		},
+		{
+			name:     "multiple filters",
+			input:    "level=error  user_id=42",
+			expected: []Filter{{Key: "level", Value: "error"}, {Key: "user_id", Value: "42"}},
+		},
+		{
+			name:     "negated filter",
+			input:    "user_id!=42",
+			expected: []Filter{{Key: "user_id", Value: "42", Negated: true}},
+		},
+		{
+			name:     "quoted value",
+			input:    `msg="connection \"refused\"" http.status=500`,
+			expected: []Filter{{Key: "msg", Value: `connection "refused"`}, {Key: "http.status", Value: "500"}},
+		},
+		{
+			name:     "empty value",
+			input:    "user=",
+			expected: []Filter{{Key: "user", Value: ""}},
+		},
+		{
+			name:     "empty input",
+			input:    " ",
+			expected: []Filter{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual, err := ParseFilters(test.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(actual) != len(test.expected) {
+				t.Fatalf("expected %v, got %v", test.expected, actual)
+			}
+
+			for i := range actual {
+				if actual[i] != test.expected[i] {
+					t.Errorf("expected %v, got %v", test.expected, actual)
+				}
+			}
+		})
+	}
+}
+
+func TestParseFiltersInvalid(t *testing.T) {
+	for _, input := range []string{"level", "=error", "!=error", `msg="unterminated`} {
+		if _, err := ParseFilters(input); err == nil {
+			t.Errorf("expected an error for `%s`", input)
+		}
+	}
+}
+
+func TestFilterMatches(t *testing.T) {
+	entry, ok := Parse([]byte(`{"level":"ERROR","user_id":42,"http":{"status":500},"ok":false,"tags":["a"]}`))
+	if !ok {
+		t.Fatal("failed to parse entry")
+	}
+
+	tests := []struct {
+		filter   string
+		expected bool
+	}{
+		{filter: "level=error", expected: true},
+		{filter: "level=warn", expected: false},
+		{filter: "user_id=42", expected: true},
+		{filter: "user_id!=42", expected: false},
+		{filter: "http.status=500", expected: true},
+		{filter: "ok=false", expected: true},
+		{filter: `tags="[\"a\"]"`, expected: true},
+		{filter: "missing=x", expected: false},
+		{filter: "missing!=x", expected: true},
+		{filter: "level=error user_id=43", expected: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.filter, func(t *testing.T) {
+			filters, err := ParseFilters(test.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if actual := MatchesAll(entry, filters); actual != test.expected {
+				t.Errorf("expected %t, got %t", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	for _, line := range []string{"", "plain text", "[1,2]", `{"a":1} trailing`, `{"a":`} {
+		if _, ok := Parse([]byte(line)); ok {
+			t.Errorf("expected `%s` not to be parsed", line)
+		}
+	}
+
+	entry, ok := Parse([]byte(`  {"id": 12345678901234567890, "a.b": "dotted", "a": {"b": "nested"}}  `))
+	if !ok {
+		t.Fatal("failed to parse entry")
+	}
+
+	if id := entry.GetString("id"); id != "12345678901234567890" {
+		t.Errorf("expected the number to keep its precision, got `%s`", id)
+	}
+
+	if value := entry.GetString("a.b"); value != "dotted" {
+		t.Errorf("expected the field with a dot to take precedence, got `%s`", value)
+	}
+}
+
+func TestDefaultColumns(t *testing.T) {
+	entries := []Entry{
+		{"ts": "2024-01-01", "msg": "started"},
+		{"severity": "info", "message": "ignored, msg comes first in the preference"},
+	}
+
+	expected := []string{"ts", "severity", "msg"}
+	actual := DefaultColumns(entries)
+	if len(actual) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, actual)
+	}
+
+	for i := range actual {
+		if actual[i] != expected[i] {
+			t.Errorf("expected %v, got %v", expected, actual)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package logs
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// limits of the labels of a log bundle
+const (
+	MaxTitleLength       = 200
+	MaxDescriptionLength = 4000
+	MaxTagCount          = 32
+	MaxTagKeyLength      = 64
+	MaxTagValueLength    = 200
+)
+
+var tagKeyPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// BundleLabels are set by the uploader to describe a log bundle.
+type BundleLabels struct {
+	Title       string            `json:"title,omitempty"`
+	Description string            `json:"description,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"`
+}
+
+// AddTag parses a tag like `os:linux` and adds it to the labels. Tags without a value,
+// like `nightly`, have an empty value.
+func (l *BundleLabels) AddTag(input string) error {
+	key, value, _ := strings.Cut(strings.TrimSpace(input), ":")
+	key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+
+	if !tagKeyPattern.MatchString(key) || len(key) > MaxTagKeyLength {
+		return fmt.Errorf("invalid tag `%s`, expected `key:value` with a key of up to %d letters, digits, `.`, `_` or `-`", input, MaxTagKeyLength)
+	}
+
+	if utf8.RuneCountInString(value) > MaxTagValueLength {
+		return fmt.Errorf("value of tag `%s` is longer than %d characters", key, MaxTagValueLength)
+	}
+
+	if l.Tags == nil {
+		l.Tags = make(map[string]string)
+	}
+
+	if _, exists := l.Tags[key]; !exists && len(l.Tags) >= MaxTagCount {
+		return fmt.Errorf("a log bundle can't have more than %d tags", MaxTagCount)
+	}
+
+	l.Tags[key] = value
+	return nil
+}
+
+// Validate checks the lengths of the title and description.
+func (l BundleLabels) Validate() error {
+	if utf8.RuneCountInString(l.Title) > MaxTitleLength {
+		return fmt.Errorf("title is longer than %d characters", MaxTitleLength)
+	}
+
+	if utf8.RuneCountInString(l.Description) > MaxDescriptionLength {
+		return fmt.Errorf("description is longer than %d characters", MaxDescriptionLength)
+	}
+
+	return nil
+}
+
+// LogBundle is the record of a log bundle.
+type LogBundle struct {
+	LogFileIds []LogFileId `json:"fileIds"`
+	BundleLabels
+	// Uploader is the name of the upload key, empty for anonymous uploads.
+	Uploader string `json:"uploader,omitempty"`
+	// Size is the number of bytes uploaded, before redaction. It's 0 for log bundles created
+	// before the size was recorded.
+	Size uint64 `json:"size,omitempty"`
+}
+
+// EncodeBundle encodes the log bundle as JSON.
+func EncodeBundle(logBundle LogBundle) (string, error) {
+	if len(logBundle.LogFileIds) < 1 {
+		return "", fmt.Errorf("log bundle must have at least one log file")
+	}
+
+	encoded, err := json.Marshal(logBundle)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal log bundle: %w", err)
+	}
+
+	return string(encoded), nil
+}
+
+// DecodeBundle decodes a log bundle encoded by EncodeBundle. Log bundles created before
+// labels were supported only contain the IDs encoded by EncodeIds.
+func DecodeBundle(input []byte) (LogBundle, error) {
+	var logBundle LogBundle
+	if len(input) == 0 || input[0] != '{' {
+		logFileIds, err := DecodeIds(input)
+		if err != nil {
+			return logBundle, err
+		}
+
+		logBundle.LogFileIds = logFileIds
+		return logBundle, nil
+	}
+
+	if err := json.Unmarshal(input, &logBundle); err != nil {
+		return logBundle, fmt.Errorf("failed to unmarshal log bundle: %w", err)
+	}
+
+	return logBundle, nil
+}
@@ -0,0 +1,84 @@
+package logs
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Level is the severity of a log line.
+type Level uint8
+
+const (
+	LevelUnknown Level = iota
+	LevelTrace
+	LevelDebug
+	LevelInfo
+	LevelWarn
+	LevelError
+	LevelFatal
+)
+
+// Levels contains all known levels from lowest to highest severity.
+var Levels = []Level{LevelTrace, LevelDebug, LevelInfo, LevelWarn, LevelError, LevelFatal}
+
+var levelNames = [...]string{"UNKNOWN", "TRACE", "DEBUG", "INFO", "WARN", "ERROR", "FATAL"}
+
+// level names and abbreviations used by common logging libraries
+var levelAliases = map[string]Level{
+	"trace":       LevelTrace,
+	"trc":         LevelTrace,
+	"verbose":     LevelTrace,
+	"vrb":         LevelTrace,
+	"debug":       LevelDebug,
+	"dbg":         LevelDebug,
+	"info":        LevelInfo,
+	"inf":         LevelInfo,
+	"information": LevelInfo,
+	"notice":      LevelInfo,
+	"warn":        LevelWarn,
+	"wrn":         LevelWarn,
+	"warning":     LevelWarn,
+	"error":       LevelError,
+	"err":         LevelError,
+	"eror":        LevelError,
+	"fatal":       LevelFatal,
+	"ftl":         LevelFatal,
+	"critical":    LevelFatal,
+	"crit":        LevelFatal,
+	"panic":       LevelFatal,
+	"alert":       LevelFatal,
+	"emergency":   LevelFatal,
+}
+
+func (l Level) String() string {
+	if int(l) >= len(levelNames) {
+		return levelNames[LevelUnknown]
+	}
+
+	return levelNames[l]
+}
+
+// ParseLevel parses level names and common abbreviations, ignoring case.
+func ParseLevel(input string) (Level, bool) {
+	level, ok := levelAliases[strings.ToLower(input)]
+	return level, ok
+}
+
+func (l Level) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+func (l *Level) UnmarshalText(text []byte) error {
+	if string(text) == levelNames[LevelUnknown] {
+		*l = LevelUnknown
+		return nil
+	}
+
+	level, ok := ParseLevel(string(text))
+	if !ok {
+		return fmt.Errorf("unknown level `%s`", string(text))
+	}
+
+	*l = level
+	return nil
+}
@@ -0,0 +1,68 @@
+package logs
+
+import (
+	"simple-log-store/internal/charset"
+	"strings"
+)
+
+// Format is the format of the lines of a log file.
+type Format string
+
+const (
+	// FormatUnknown is the format of log files that were indexed before formats were detected.
+	FormatUnknown   Format = ""
+	FormatText      Format = "text"
+	FormatJsonLines Format = "ndjson"
+)
+
+// RedactionCounts contains the number of redacted values per detector.
+type RedactionCounts map[string]uint64
+
+// Total returns the number of redacted values of all detectors.
+func (c RedactionCounts) Total() uint64 {
+	var total uint64
+	for _, count := range c {
+		total += count
+	}
+
+	return total
+}
+
+// LogFileMetadata contains information about a log file that is computed once
+// after the log file has been committed.
+type LogFileMetadata struct {
+	LineCount uint64
+	Format    Format
+	// LevelCounts contains the number of log entries per level. Lines that continue a
+	// previous entry, like stack traces, are not counted.
+	LevelCounts map[Level]uint64
+	// Name, Redactions, ContentType and Encoding are recorded while staging the log file instead
+	// of after committing it. Name is the original file name of the upload and can be empty.
+	Name       string
+	Redactions RedactionCounts
+	// ContentType is the detected media type, like `text/plain`. It's empty for log files that
+	// were staged before content types were detected.
+	ContentType string
+	// Encoding is the detected character encoding of text files, the lines are decoded to UTF-8
+	// when they are read.
+	Encoding charset.Encoding
+	// Quarantine is the reason the log file was rejected by an ingestion hook, it's empty
+	// for log files that were committed.
+	Quarantine string
+}
+
+// IsText returns false if the log file isn't text, like an archive that was uploaded by mistake.
+// Log files without a content type are assumed to be text.
+func (m LogFileMetadata) IsText() bool {
+	return m.ContentType == "" || strings.HasPrefix(m.ContentType, "text/")
+}
+
+// CountLevels returns the number of log entries with any of the levels.
+func (m LogFileMetadata) CountLevels(levels ...Level) uint64 {
+	var count uint64
+	for _, level := range levels {
+		count += m.LevelCounts[level]
+	}
+
+	return count
+}
@@ -0,0 +1,16 @@
+package logs
+
+import "time"
+
+// Signature groups exceptions with the same fingerprint across log bundles.
+type Signature struct {
+	Fingerprint string
+	Exception   string
+	Message     string
+	// BundleCount is the number of stored log bundles with the exception, including pinned ones.
+	BundleCount int64
+	FirstSeen   time.Time
+	LastSeen    time.Time
+	// ExampleBundles contains the most recent log bundles with the exception.
+	ExampleBundles []LogBundleId
+}
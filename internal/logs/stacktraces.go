@@ -0,0 +1,15 @@
+package logs
+
+// StackTrace is a stack trace block in a log file. Line numbers are 1-based and inclusive.
+type StackTrace struct {
+	FirstLine uint64 `json:"firstLine"`
+	LastLine  uint64 `json:"lastLine"`
+	// FirstFrame and LastFrame are the lines with the frames, which can be folded.
+	FirstFrame uint64 `json:"firstFrame"`
+	LastFrame  uint64 `json:"lastFrame"`
+	// Exception is the type of the exception, like `System.NullReferenceException`.
+	Exception string `json:"exception"`
+	Message   string `json:"message"`
+	// Fingerprint is the same for exceptions of the same type thrown at the same location.
+	Fingerprint string `json:"fingerprint"`
+}
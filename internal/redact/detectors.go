@@ -0,0 +1,110 @@
+package redact
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+)
+
+// Detector finds one kind of sensitive data. If the pattern has a capture group, only the
+// first group is replaced, so the surrounding context stays readable.
+type Detector struct {
+	Name    string
+	Pattern *regexp.Regexp
+}
+
+var builtinDetectors = []Detector{
+	{
+		Name:    "jwt",
+		Pattern: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{4,}\.[A-Za-z0-9_-]{4,}\.[A-Za-z0-9_-]*`),
+	},
+	{
+		Name:    "bearer",
+		Pattern: regexp.MustCompile(`(?i)\bbearer\s+([A-Za-z0-9._~+/-]{8,}=*)`),
+	},
+	{
+		Name:    "aws",
+		Pattern: regexp.MustCompile(`\b(?:AKIA|ASIA|AGPA|AIDA|AROA|ANPA|ANVA|AIPA)[0-9A-Z]{16}\b`),
+	},
+	{
+		Name:    "aws",
+		Pattern: regexp.MustCompile(`(?i)aws_?secret_?access_?key["']?\s*[=:]\s*["']?([A-Za-z0-9/+=]{40})`),
+	},
+	{
+		Name:    "email",
+		Pattern: regexp.MustCompile(`\b[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}\b`),
+	},
+	{
+		Name:    "ip",
+		Pattern: regexp.MustCompile(`\b(?:(?:25[0-5]|2[0-4]\d|1\d\d|[1-9]?\d)\.){3}(?:25[0-5]|2[0-4]\d|1\d\d|[1-9]?\d)\b`),
+	},
+	{
+		Name:    "ip",
+		Pattern: regexp.MustCompile(`\b(?:[0-9A-Fa-f]{1,4}:){7}[0-9A-Fa-f]{1,4}\b`),
+	},
+	{
+		// C:\Users\name, /home/name and /Users/name
+		Name:    "username",
+		Pattern: regexp.MustCompile(`(?i)(?:\b[A-Z]:[\\/]+(?:Users|Documents and Settings)[\\/]+|/home/|/Users/)([^\\/\s"':*?<>|]+)`),
+	},
+}
+
+// BuiltinDetectorNames returns the names of all built-in detectors.
+func BuiltinDetectorNames() []string {
+	var names []string
+	for i, detector := range builtinDetectors {
+		if i == 0 || builtinDetectors[i-1].Name != detector.Name {
+			names = append(names, detector.Name)
+		}
+	}
+
+	return names
+}
+
+// GetBuiltinDetectors returns the built-in detectors with the given names.
+func GetBuiltinDetectors(names []string) ([]Detector, error) {
+	var detectors []Detector
+	for _, name := range names {
+		found := false
+		for _, detector := range builtinDetectors {
+			if detector.Name == name {
+				detectors = append(detectors, detector)
+				found = true
+			}
+		}
+
+		if !found {
+			return nil, fmt.Errorf("unknown redaction detector `%s`, expected one of %v", name, BuiltinDetectorNames())
+		}
+	}
+
+	return detectors, nil
+}
+
+// CompileDetectors compiles custom detectors where the key is the name of the detector
+// and the value is the regular expression.
+func CompileDetectors(patterns map[string]string) ([]Detector, error) {
+	names := make([]string, 0, len(patterns))
+	for name := range patterns {
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+
+	detectors := make([]Detector, 0, len(patterns))
+	for _, name := range names {
+		pattern := patterns[name]
+		compiled, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compile redaction pattern `%s`: %w", name, err)
+		}
+
+		if compiled.NumSubexp() > 1 {
+			return nil, fmt.Errorf("redaction pattern `%s` must have at most one capture group", name)
+		}
+
+		detectors = append(detectors, Detector{Name: name, Pattern: compiled})
+	}
+
+	return detectors, nil
+}
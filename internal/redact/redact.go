@@ -0,0 +1,119 @@
+// Package redact removes secrets and personal data from log files before they are stored.
+package redact
+
+import (
+	"bytes"
+	"io"
+	"simple-log-store/internal/logs"
+)
+
+// Redactor replaces everything found by its detectors with `[REDACTED:<name>]`.
+type Redactor struct {
+	detectors []Detector
+}
+
+func New(detectors []Detector) *Redactor {
+	return &Redactor{detectors: detectors}
+}
+
+// Line redacts a single line and adds the number of replacements per detector to the counts.
+// The returned slice is the input if nothing was replaced.
+func (r *Redactor) Line(line []byte, counts logs.RedactionCounts) []byte {
+	for _, detector := range r.detectors {
+		matches := detector.Pattern.FindAllSubmatchIndex(line, -1)
+		if len(matches) == 0 {
+			continue
+		}
+
+		replacement := []byte("[REDACTED:" + detector.Name + "]")
+		res := make([]byte, 0, len(line))
+		last := 0
+
+		for _, match := range matches {
+			start, end := match[0], match[1]
+			// only replace the capture group if the pattern has one
+			if len(match) >= 4 && match[2] != -1 {
+				start, end = match[2], match[3]
+			}
+
+			res = append(res, line[last:start]...)
+			res = append(res, replacement...)
+			last = end
+		}
+
+		line = append(res, line[last:]...)
+		counts[detector.Name] += uint64(len(matches))
+	}
+
+	return line
+}
+
+// Writer redacts the data written to the underlying writer line by line. Close must be
+// called to write the last line if it doesn't end with a newline.
+type Writer struct {
+	redactor *Redactor
+	writer   io.Writer
+	counts   logs.RedactionCounts
+	buffer   []byte
+	err      error
+}
+
+func (r *Redactor) NewWriter(writer io.Writer) *Writer {
+	return &Writer{
+		redactor: r,
+		writer:   writer,
+		counts:   make(logs.RedactionCounts),
+	}
+}
+
+func (w *Writer) Write(data []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+
+	w.buffer = append(w.buffer, data...)
+
+	// lines are written as soon as they are complete, the rest stays in the buffer
+	written := 0
+	for {
+		index := bytes.IndexByte(w.buffer[written:], '\n')
+		if index == -1 {
+			break
+		}
+
+		end := written + index + 1
+		if w.err = w.writeLine(w.buffer[written:end]); w.err != nil {
+			return 0, w.err
+		}
+
+		written = end
+	}
+
+	w.buffer = append(w.buffer[:0], w.buffer[written:]...)
+	return len(data), nil
+}
+
+func (w *Writer) writeLine(line []byte) error {
+	_, err := w.writer.Write(w.redactor.Line(line, w.counts))
+	return err
+}
+
+// Close writes the remaining data without a trailing newline. It doesn't close the
+// underlying writer.
+func (w *Writer) Close() error {
+	if w.err != nil {
+		return w.err
+	}
+
+	if len(w.buffer) != 0 {
+		w.err = w.writeLine(w.buffer)
+		w.buffer = nil
+	}
+
+	return w.err
+}
+
+// Counts returns the number of replacements per detector.
+func (w *Writer) Counts() logs.RedactionCounts {
+	return w.counts
+}
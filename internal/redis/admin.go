@@ -0,0 +1,316 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"log/slog"
+	"simple-log-store/internal/logs"
+	"simple-log-store/internal/utils"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sorted set of log files that couldn't be moved from the staging to the storage, scored by
+// the time of the failure in milliseconds
+const failedCommitsKey = "failedCommits"
+
+// sorted set of samples of the storage usage, scored by the time of the sample in seconds
+const storageUsageKey = "storageUsage"
+
+// FailedCommit is a log file that was uploaded but never moved to the storage.
+type FailedCommit struct {
+	LogBundleId logs.LogBundleId `json:"bundleId"`
+	LogFileId   logs.LogFileId   `json:"fileId"`
+	FailedAt    time.Time        `json:"failedAt"`
+	// Reason is only known for log files that were quarantined, other errors are only logged.
+	Reason string `json:"reason,omitempty"`
+}
+
+// RecordFailedCommits stores the log files that failed to commit with their reasons, which can
+// be empty. Entries are removed once they are older than the retention duration.
+func (s *Service) RecordFailedCommits(ctx context.Context, logBundleId logs.LogBundleId, reasons map[logs.LogFileId]string) error {
+	now := time.Now()
+	members := make([]redis.Z, 0, len(reasons))
+	for logFileId, reason := range reasons {
+		encoded, err := json.Marshal(FailedCommit{LogBundleId: logBundleId, LogFileId: logFileId, FailedAt: now.UTC(), Reason: reason})
+		if err != nil {
+			return fmt.Errorf("failed to marshal failed commit: %w", err)
+		}
+
+		members = append(members, redis.Z{Score: float64(now.UnixMilli()), Member: string(encoded)})
+	}
+
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZAdd(ctx, failedCommitsKey, members...)
+		pipe.ZRemRangeByScore(ctx, failedCommitsKey, "-inf", "("+strconv.FormatInt(now.Add(-s.logRetentionDuration).UnixMilli(), 10))
+		return nil
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to record failed commits of log bundle `%s`: %w", logBundleId.String(), err)
+	}
+
+	return nil
+}
+
+// GetFailedCommits returns up to limit failed commits, the most recent first.
+func (s *Service) GetFailedCommits(ctx context.Context, limit int) ([]FailedCommit, error) {
+	members, err := s.client.ZRevRange(ctx, failedCommitsKey, 0, int64(limit-1)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get failed commits: %w", err)
+	}
+
+	res := make([]FailedCommit, 0, len(members))
+	for _, member := range members {
+		var failedCommit FailedCommit
+		if err := json.Unmarshal([]byte(member), &failedCommit); err != nil {
+			s.logger.Warn("skipping failed commit that can't be decoded", utils.ErrAttr(err))
+			continue
+		}
+
+		res = append(res, failedCommit)
+	}
+
+	return res, nil
+}
+
+// StorageUsageSample is the size of the storage directory at a point in time.
+type StorageUsageSample struct {
+	Time      time.Time
+	Bytes     int64
+	FileCount int
+}
+
+// RecordStorageUsage adds a sample of the storage usage. Samples are kept for the retention duration.
+func (s *Service) RecordStorageUsage(ctx context.Context, sample StorageUsageSample) error {
+	member := fmt.Sprintf("%d:%d:%d", sample.Time.Unix(), sample.Bytes, sample.FileCount)
+
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZAdd(ctx, storageUsageKey, redis.Z{Score: float64(sample.Time.Unix()), Member: member})
+		pipe.ZRemRangeByScore(ctx, storageUsageKey, "-inf", "("+strconv.FormatInt(sample.Time.Add(-s.logRetentionDuration).Unix(), 10))
+		return nil
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to record storage usage: %w", err)
+	}
+
+	return nil
+}
+
+// GetStorageUsageHistory returns all recorded samples of the storage usage, the oldest first.
+func (s *Service) GetStorageUsageHistory(ctx context.Context) ([]StorageUsageSample, error) {
+	members, err := s.client.ZRange(ctx, storageUsageKey, 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get storage usage: %w", err)
+	}
+
+	res := make([]StorageUsageSample, 0, len(members))
+	for _, member := range members {
+		parts := strings.Split(member, ":")
+		if len(parts) != 3 {
+			s.logger.Warn("skipping invalid storage usage sample", slog.String("member", member))
+			continue
+		}
+
+		timestamp, timeErr := strconv.ParseInt(parts[0], 10, 64)
+		bytes, bytesErr := strconv.ParseInt(parts[1], 10, 64)
+		fileCount, fileCountErr := strconv.Atoi(parts[2])
+		if timeErr != nil || bytesErr != nil || fileCountErr != nil {
+			s.logger.Warn("skipping invalid storage usage sample", slog.String("member", member))
+			continue
+		}
+
+		res = append(res, StorageUsageSample{Time: time.Unix(timestamp, 0), Bytes: bytes, FileCount: fileCount})
+	}
+
+	return res, nil
+}
+
+// UploaderCount is the number of log bundles of an uploader.
+type UploaderCount struct {
+	Uploader    string
+	BundleCount int64
+}
+
+// GetTopUploaders returns up to limit uploaders with the most log bundles. Anonymous uploads
+// aren't included.
+func (s *Service) GetTopUploaders(ctx context.Context, limit int) ([]UploaderCount, error) {
+	counts, err := s.client.ZRevRangeWithScores(ctx, uploaderCountsKey, 0, int64(limit-1)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get uploader counts: %w", err)
+	}
+
+	res := make([]UploaderCount, 0, len(counts))
+	for _, count := range counts {
+		uploader, ok := count.Member.(string)
+		if !ok || count.Score <= 0 {
+			continue
+		}
+
+		res = append(res, UploaderCount{Uploader: uploader, BundleCount: int64(count.Score)})
+	}
+
+	sort.SliceStable(res, func(i, j int) bool {
+		if res[i].BundleCount != res[j].BundleCount {
+			return res[i].BundleCount > res[j].BundleCount
+		}
+
+		return res[i].Uploader < res[j].Uploader
+	})
+
+	return res, nil
+}
+
+// getListedBundles returns the log bundles that still exist, in the order of the IDs.
+func (s *Service) getListedBundles(ctx context.Context, logBundleIds []string) ([]ListedBundle, error) {
+	if len(logBundleIds) == 0 {
+		return nil, nil
+	}
+
+	keys := make([]string, len(logBundleIds))
+	for i, logBundleId := range logBundleIds {
+		keys[i] = getKey(logBundlesNamespace, logBundleId)
+	}
+
+	values, err := s.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get log bundles: %w", err)
+	}
+
+	res := make([]ListedBundle, 0, len(values))
+	for i, value := range values {
+		encoded, ok := value.(string)
+		if !ok {
+			continue
+		}
+
+		logBundleId, err := logs.ParseId(logBundleIds[i])
+		if err != nil {
+			s.logger.Warn("skipping invalid log bundle ID", slog.String("logBundleId", logBundleIds[i]))
+			continue
+		}
+
+		logBundle, err := logs.DecodeBundle([]byte(encoded))
+		if err != nil {
+			s.logger.Warn("skipping log bundle that can't be decoded", slog.String("logBundleId", logBundleIds[i]), utils.ErrAttr(err))
+			continue
+		}
+
+		res = append(res, ListedBundle{LogBundleId: logBundleId, LogBundle: logBundle})
+	}
+
+	return res, nil
+}
+
+// GetPinnedLogBundles returns all pinned log bundles, the newest first.
+func (s *Service) GetPinnedLogBundles(ctx context.Context) ([]ListedBundle, error) {
+	members, err := s.client.SMembers(ctx, pinnedLogBundlesKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pinned log bundles: %w", err)
+	}
+
+	sort.Sort(sort.Reverse(sort.StringSlice(members)))
+	return s.getListedBundles(ctx, members)
+}
+
+// ExpiringBundle is a log bundle with the time it expires.
+type ExpiringBundle struct {
+	ListedBundle
+	ExpiresAt time.Time
+}
+
+// GetExpiringLogBundles returns up to limit log bundles that expire before the deadline, the
+// first to expire first. Log bundles that weren't extended expire in the order they were
+// created, so only the oldest are checked. Pinned and extended log bundles are skipped while
+// reading the oldest, and extended log bundles are added based on their extension.
+func (s *Service) GetExpiringLogBundles(ctx context.Context, deadline time.Time, limit int) ([]ExpiringBundle, error) {
+	pinned, err := s.client.SMembers(ctx, pinnedLogBundlesKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pinned log bundles: %w", err)
+	}
+
+	extended, err := s.client.ZRangeWithScores(ctx, extendedLogBundlesKey, 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get extended log bundles: %w", err)
+	}
+
+	skipped := make(map[string]struct{}, len(pinned)+len(extended))
+	for _, member := range pinned {
+		skipped[member] = struct{}{}
+	}
+
+	var members []string
+	for _, extension := range extended {
+		member, ok := extension.Member.(string)
+		if !ok {
+			continue
+		}
+
+		skipped[member] = struct{}{}
+		if !time.Unix(int64(extension.Score), 0).After(deadline) {
+			members = append(members, member)
+		}
+	}
+
+	// every skipped log bundle can take the place of one that expires
+	oldest, err := s.client.ZRangeByLex(ctx, bundleIndexKey, &redis.ZRangeBy{
+		Min:   "-",
+		Max:   "[" + getTimeBound(deadline.Add(-s.logRetentionDuration), true),
+		Count: int64(limit + len(skipped)),
+	}).Result()
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to read index `%s`: %w", bundleIndexKey, err)
+	}
+
+	for _, member := range oldest {
+		if _, ok := skipped[member]; !ok {
+			members = append(members, member)
+		}
+	}
+
+	logBundles, err := s.getListedBundles(ctx, members)
+	if err != nil {
+		return nil, err
+	}
+
+	cmds, err := s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, logBundle := range logBundles {
+			pipe.TTL(ctx, getKey(logBundlesNamespace, logBundle.LogBundleId.String()))
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get TTL of log bundles: %w", err)
+	}
+
+	now := time.Now()
+	var res []ExpiringBundle
+	for i, cmd := range cmds {
+		// pinned and already expired log bundles have a negative TTL
+		ttl := cmd.(*redis.DurationCmd).Val()
+		if ttl < 0 {
+			continue
+		}
+
+		expiresAt := now.Add(ttl)
+		if expiresAt.After(deadline) {
+			continue
+		}
+
+		res = append(res, ExpiringBundle{ListedBundle: logBundles[i], ExpiresAt: expiresAt})
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].ExpiresAt.Before(res[j].ExpiresAt)
+	})
+
+	return res[:min(limit, len(res))], nil
+}
@@ -0,0 +1,269 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"simple-log-store/internal/logs"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// set containing the IDs of all log bundles that are exempt from the retention
+const pinnedLogBundlesKey = "pinnedLogBundles"
+
+// sorted set containing the IDs of all extended log bundles, scored by their new expiry as a Unix timestamp
+const extendedLogBundlesKey = "extendedLogBundles"
+
+const scanCount = 1000
+
+// ListLogBundles returns the IDs of all log bundles using SCAN.
+func (s *Service) ListLogBundles(ctx context.Context) ([]logs.LogBundleId, error) {
+	var res []logs.LogBundleId
+
+	iter := s.client.Scan(ctx, 0, getKey(logBundlesNamespace, "*"), scanCount).Iterator()
+	for iter.Next(ctx) {
+		key := iter.Val()
+		idInput := strings.TrimPrefix(key, logBundlesNamespace+":")
+
+		logBundleId, err := logs.ParseId(idInput)
+		if err != nil {
+			s.logger.Warn("skipping key with invalid log bundle ID")
+			continue
+		}
+
+		res = append(res, logBundleId)
+	}
+
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("failed to scan log bundles: %w", err)
+	}
+
+	return res, nil
+}
+
+// GetLogBundleExpiry returns the remaining time to live of the log bundle. The second
+// return value is false if the log bundle doesn't expire.
+func (s *Service) GetLogBundleExpiry(ctx context.Context, logBundleId logs.LogBundleId) (time.Duration, bool, error) {
+	ttl, err := s.client.TTL(ctx, getKey(logBundlesNamespace, logBundleId.String())).Result()
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to get TTL of log bundle `%s`: %w", logBundleId.String(), err)
+	}
+
+	// https://redis.io/docs/latest/commands/ttl/
+	switch ttl {
+	case -2:
+		return 0, false, fmt.Errorf("unable to find log bundle with ID `%s`: %w", logBundleId.String(), ErrNotFound)
+	case -1:
+		return 0, false, nil
+	default:
+		return ttl, true, nil
+	}
+}
+
+// DeleteLogBundle removes the log bundle and the metadata of its log files. The log files
+// themselves are not removed.
+func (s *Service) DeleteLogBundle(ctx context.Context, logBundleId logs.LogBundleId) error {
+	logBundle, err := s.GetLogBundleRecord(ctx, logBundleId)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+
+	logFileIds := logBundle.LogFileIds
+
+	var fingerprints []string
+	_, err = s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		fingerprints, err = s.removeSignatures(ctx, pipe, logBundleId, logFileIds)
+		if err != nil {
+			return err
+		}
+
+		pipe.Del(ctx, getKey(logBundlesNamespace, logBundleId.String()))
+		removeFromBundleIndexes(ctx, pipe, logBundleId, logBundle)
+		for _, logFileId := range logFileIds {
+			pipe.Del(ctx, getLogFileKeys(logFileId)...)
+		}
+
+		pipe.SRem(ctx, pinnedLogBundlesKey, logBundleId.String())
+		pipe.ZRem(ctx, extendedLogBundlesKey, logBundleId.String())
+		return nil
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to delete log bundle `%s`: %w", logBundleId.String(), err)
+	}
+
+	return s.updateSignatureCounts(ctx, fingerprints)
+}
+
+// PinLogBundle removes the expiry of the log bundle and its log files and marks it as pinned.
+func (s *Service) PinLogBundle(ctx context.Context, logBundleId logs.LogBundleId) error {
+	logFileIds, err := s.GetLogBundle(ctx, logBundleId)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Persist(ctx, getKey(logBundlesNamespace, logBundleId.String()))
+		for _, logFileId := range logFileIds {
+			for _, key := range getLogFileKeys(logFileId) {
+				pipe.Persist(ctx, key)
+			}
+		}
+
+		pipe.SAdd(ctx, pinnedLogBundlesKey, logBundleId.String())
+		return nil
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to pin log bundle `%s`: %w", logBundleId.String(), err)
+	}
+
+	return nil
+}
+
+// UnpinLogBundle restores the expiry of the log bundle and its log files based on the time they were
+// created. Extensions are discarded as well.
+func (s *Service) UnpinLogBundle(ctx context.Context, logBundleId logs.LogBundleId) error {
+	logFileIds, err := s.GetLogBundle(ctx, logBundleId)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ExpireAt(ctx, getKey(logBundlesNamespace, logBundleId.String()), s.getExpiresAt(logBundleId))
+		for _, logFileId := range logFileIds {
+			for _, key := range getLogFileKeys(logFileId) {
+				pipe.ExpireAt(ctx, key, s.getExpiresAt(logFileId))
+			}
+		}
+
+		pipe.SRem(ctx, pinnedLogBundlesKey, logBundleId.String())
+		pipe.ZRem(ctx, extendedLogBundlesKey, logBundleId.String())
+		return nil
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to unpin log bundle `%s`: %w", logBundleId.String(), err)
+	}
+
+	return nil
+}
+
+// ExtendLogBundle postpones the expiry of the log bundle and its log files by the duration and
+// returns the new expiry. Pinned log bundles can't be extended because they don't expire.
+func (s *Service) ExtendLogBundle(ctx context.Context, logBundleId logs.LogBundleId, duration time.Duration) (time.Time, error) {
+	ttl, expires, err := s.GetLogBundleExpiry(ctx, logBundleId)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if !expires {
+		return time.Time{}, fmt.Errorf("log bundle `%s` is pinned and doesn't expire", logBundleId.String())
+	}
+
+	logFileIds, err := s.GetLogBundle(ctx, logBundleId)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	expiresAt := time.Now().Add(ttl + duration).Truncate(time.Second)
+	_, err = s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ExpireAt(ctx, getKey(logBundlesNamespace, logBundleId.String()), expiresAt)
+		for _, logFileId := range logFileIds {
+			for _, key := range getLogFileKeys(logFileId) {
+				pipe.ExpireAt(ctx, key, expiresAt)
+			}
+		}
+
+		pipe.ZAdd(ctx, extendedLogBundlesKey, redis.Z{Score: float64(expiresAt.Unix()), Member: logBundleId.String()})
+		return nil
+	})
+
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to extend log bundle `%s`: %w", logBundleId.String(), err)
+	}
+
+	return expiresAt, nil
+}
+
+func (s *Service) IsLogBundlePinned(ctx context.Context, logBundleId logs.LogBundleId) (bool, error) {
+	isPinned, err := s.client.SIsMember(ctx, pinnedLogBundlesKey, logBundleId.String()).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to check if log bundle `%s` is pinned: %w", logBundleId.String(), err)
+	}
+
+	return isPinned, nil
+}
+
+// GetPinnedLogFiles returns the IDs of all log files that are referenced by pinned log bundles.
+func (s *Service) GetPinnedLogFiles(ctx context.Context) (map[logs.LogFileId]struct{}, error) {
+	members, err := s.client.SMembers(ctx, pinnedLogBundlesKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pinned log bundles: %w", err)
+	}
+
+	res := make(map[logs.LogFileId]struct{})
+	for _, member := range members {
+		logBundleId, err := logs.ParseId(member)
+		if err != nil {
+			s.logger.Warn("skipping invalid pinned log bundle ID")
+			continue
+		}
+
+		logFileIds, err := s.GetLogBundle(ctx, logBundleId)
+		if err != nil {
+			if errors.Is(err, ErrNotFound) {
+				continue
+			}
+
+			return nil, err
+		}
+
+		for _, logFileId := range logFileIds {
+			res[logFileId] = struct{}{}
+		}
+	}
+
+	return res, nil
+}
+
+// GetExtendedLogFiles returns the IDs of all log files that are referenced by log bundles whose
+// extension hasn't run out yet. Log bundles with an extension in the past are removed.
+func (s *Service) GetExtendedLogFiles(ctx context.Context) (map[logs.LogFileId]struct{}, error) {
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	if err := s.client.ZRemRangeByScore(ctx, extendedLogBundlesKey, "-inf", "("+now).Err(); err != nil {
+		return nil, fmt.Errorf("failed to remove expired extensions: %w", err)
+	}
+
+	members, err := s.client.ZRange(ctx, extendedLogBundlesKey, 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get extended log bundles: %w", err)
+	}
+
+	res := make(map[logs.LogFileId]struct{})
+	for _, member := range members {
+		logBundleId, err := logs.ParseId(member)
+		if err != nil {
+			s.logger.Warn("skipping invalid extended log bundle ID")
+			continue
+		}
+
+		logFileIds, err := s.GetLogBundle(ctx, logBundleId)
+		if err != nil {
+			if errors.Is(err, ErrNotFound) {
+				continue
+			}
+
+			return nil, err
+		}
+
+		for _, logFileId := range logFileIds {
+			res[logFileId] = struct{}{}
+		}
+	}
+
+	return res, nil
+}
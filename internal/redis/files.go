@@ -0,0 +1,334 @@
+package redis
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/oklog/ulid/v2"
+	"github.com/redis/go-redis/v9"
+	"simple-log-store/internal/charset"
+	"simple-log-store/internal/logs"
+	"strconv"
+	"time"
+)
+
+// namespace contains a hash for every log file with metadata computed after committing the log file
+// and the name and redactions recorded while staging it
+const logFilesNamespace = "logFiles"
+
+// namespace contains the level of every line of a log file, encoded as one byte per line
+const logFileLevelsNamespace = "logFileLevels"
+
+// namespace contains the stack traces of a log file encoded as JSON
+const logFileStackTracesNamespace = "logFileStackTraces"
+
+// namespace contains the sparse line offset index of a log file, encoded as 8 bytes per offset
+const logFileLineOffsetsNamespace = "logFileLineOffsets"
+
+const (
+	lineCountField   = "lineCount"
+	levelCountsField = "levelCounts"
+	formatField      = "format"
+	redactionsField  = "redactions"
+	nameField        = "name"
+	contentTypeField = "contentType"
+	encodingField    = "encoding"
+	quarantineField  = "quarantine"
+)
+
+// getLogFileKeys returns all keys that contain data of the log file.
+func getLogFileKeys(logFileId logs.LogFileId) []string {
+	return []string{
+		getKey(logFilesNamespace, logFileId.String()),
+		getKey(logFileLevelsNamespace, logFileId.String()),
+		getKey(logFileStackTracesNamespace, logFileId.String()),
+		getKey(logFileLineOffsetsNamespace, logFileId.String()),
+	}
+}
+
+// getExpiresAt returns the time the data of the log file expires, based on the time the ID was created.
+func (s *Service) getExpiresAt(id ulid.ULID) time.Time {
+	return ulid.Time(id.Time()).Add(s.logRetentionDuration)
+}
+
+// getLogFileExpiry returns the time the data of the log file expires. The metadata of the log
+// file is stored while staging it and pinning or extending its log bundle changes the expiry
+// of the metadata, so it's used unless it expires earlier than the retention duration. The
+// second return value is false if the log file doesn't expire.
+func (s *Service) getLogFileExpiry(ctx context.Context, logFileId logs.LogFileId) (time.Time, bool, error) {
+	expiresAt := s.getExpiresAt(logFileId)
+
+	ttl, err := s.client.PTTL(ctx, getKey(logFilesNamespace, logFileId.String())).Result()
+	if err != nil {
+		return expiresAt, true, err
+	}
+
+	// https://redis.io/docs/latest/commands/pttl/
+	switch {
+	case ttl == -1:
+		return time.Time{}, false, nil
+	case ttl > 0 && time.Now().Add(ttl).After(expiresAt):
+		return time.Now().Add(ttl), true, nil
+	default:
+		return expiresAt, true, nil
+	}
+}
+
+// SetLogFileIndex stores the level of every line of the log file, its stack traces, the byte
+// offsets of some of its lines and its metadata.
+func (s *Service) SetLogFileIndex(ctx context.Context, logFileId logs.LogFileId, levels []logs.Level, stackTraces []logs.StackTrace, lineOffsets []int64, metadata logs.LogFileMetadata) error {
+	encodedLevels := make([]byte, len(levels))
+	for i, level := range levels {
+		encodedLevels[i] = byte(level)
+	}
+
+	encodedLineOffsets := make([]byte, 0, len(lineOffsets)*8)
+	for _, offset := range lineOffsets {
+		encodedLineOffsets = binary.BigEndian.AppendUint64(encodedLineOffsets, uint64(offset))
+	}
+
+	encodedLevelCounts, err := json.Marshal(metadata.LevelCounts)
+	if err != nil {
+		return fmt.Errorf("failed to encode level counts of log file `%s`: %w", logFileId.String(), err)
+	}
+
+	encodedStackTraces, err := json.Marshal(stackTraces)
+	if err != nil {
+		return fmt.Errorf("failed to encode stack traces of log file `%s`: %w", logFileId.String(), err)
+	}
+
+	// files of pinned or extended bundles can outlive their retention period
+	expiresAt, expires, err := s.getLogFileExpiry(ctx, logFileId)
+	if err != nil {
+		return fmt.Errorf("failed to get expiry of log file `%s`: %w", logFileId.String(), err)
+	}
+
+	metadataKey := getKey(logFilesNamespace, logFileId.String())
+	levelsKey := getKey(logFileLevelsNamespace, logFileId.String())
+	stackTracesKey := getKey(logFileStackTracesNamespace, logFileId.String())
+	lineOffsetsKey := getKey(logFileLineOffsetsNamespace, logFileId.String())
+
+	_, err = s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, metadataKey, lineCountField, metadata.LineCount, levelCountsField, encodedLevelCounts, formatField, string(metadata.Format))
+		pipe.Set(ctx, levelsKey, encodedLevels, 0)
+		pipe.Set(ctx, stackTracesKey, encodedStackTraces, 0)
+		pipe.Set(ctx, lineOffsetsKey, encodedLineOffsets, 0)
+
+		if expires {
+			pipe.ExpireAt(ctx, metadataKey, expiresAt)
+			pipe.ExpireAt(ctx, levelsKey, expiresAt)
+			pipe.ExpireAt(ctx, stackTracesKey, expiresAt)
+			pipe.ExpireAt(ctx, lineOffsetsKey, expiresAt)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to store index of log file `%s`: %w", logFileId.String(), err)
+	}
+
+	return nil
+}
+
+// SetStagedLogFileMetadata stores the original file name, the content type, the encoding and
+// the number of redacted values of the log file. It's called while staging the log file, before
+// the rest of the metadata exists, so all other fields are ignored.
+func (s *Service) SetStagedLogFileMetadata(ctx context.Context, logFileId logs.LogFileId, metadata logs.LogFileMetadata) error {
+	values := make([]any, 0, 8)
+	if metadata.Name != "" {
+		values = append(values, nameField, metadata.Name)
+	}
+
+	if metadata.ContentType != "" {
+		values = append(values, contentTypeField, metadata.ContentType)
+	}
+
+	if metadata.Encoding != charset.Unknown {
+		values = append(values, encodingField, string(metadata.Encoding))
+	}
+
+	if len(metadata.Redactions) != 0 {
+		encodedRedactions, err := json.Marshal(metadata.Redactions)
+		if err != nil {
+			return fmt.Errorf("failed to encode redactions of log file `%s`: %w", logFileId.String(), err)
+		}
+
+		values = append(values, redactionsField, encodedRedactions)
+	}
+
+	if len(values) == 0 {
+		return nil
+	}
+
+	metadataKey := getKey(logFilesNamespace, logFileId.String())
+
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, metadataKey, values...)
+		pipe.ExpireAt(ctx, metadataKey, s.getExpiresAt(logFileId))
+		return nil
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to store staged metadata of log file `%s`: %w", logFileId.String(), err)
+	}
+
+	return nil
+}
+
+// SetLogFileQuarantine records why the log file was rejected by an ingestion hook instead of being committed.
+func (s *Service) SetLogFileQuarantine(ctx context.Context, logFileId logs.LogFileId, reason string) error {
+	metadataKey := getKey(logFilesNamespace, logFileId.String())
+
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, metadataKey, quarantineField, reason)
+		pipe.ExpireAt(ctx, metadataKey, s.getExpiresAt(logFileId))
+		return nil
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to store quarantine of log file `%s`: %w", logFileId.String(), err)
+	}
+
+	return nil
+}
+
+// GetLogFileLevels returns the level of every line of the log file.
+func (s *Service) GetLogFileLevels(ctx context.Context, logFileId logs.LogFileId) ([]logs.Level, error) {
+	encodedLevels, err := s.client.Get(ctx, getKey(logFileLevelsNamespace, logFileId.String())).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, fmt.Errorf("unable to find levels of log file `%s`: %w", logFileId.String(), ErrNotFound)
+		}
+
+		return nil, fmt.Errorf("failed to get levels of log file `%s`: %w", logFileId.String(), err)
+	}
+
+	levels := make([]logs.Level, len(encodedLevels))
+	for i, encodedLevel := range encodedLevels {
+		levels[i] = logs.Level(encodedLevel)
+	}
+
+	return levels, nil
+}
+
+// GetLogFileLineOffsets returns the byte offsets of every line of the log file that's in the
+// line offset index.
+func (s *Service) GetLogFileLineOffsets(ctx context.Context, logFileId logs.LogFileId) ([]int64, error) {
+	encodedLineOffsets, err := s.client.Get(ctx, getKey(logFileLineOffsetsNamespace, logFileId.String())).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, fmt.Errorf("unable to find line offsets of log file `%s`: %w", logFileId.String(), ErrNotFound)
+		}
+
+		return nil, fmt.Errorf("failed to get line offsets of log file `%s`: %w", logFileId.String(), err)
+	}
+
+	if len(encodedLineOffsets)%8 != 0 {
+		return nil, fmt.Errorf("invalid line offsets of log file `%s`", logFileId.String())
+	}
+
+	lineOffsets := make([]int64, len(encodedLineOffsets)/8)
+	for i := range lineOffsets {
+		lineOffsets[i] = int64(binary.BigEndian.Uint64(encodedLineOffsets[i*8:]))
+	}
+
+	return lineOffsets, nil
+}
+
+// GetLogFileStackTraces returns the stack traces of the log file.
+func (s *Service) GetLogFileStackTraces(ctx context.Context, logFileId logs.LogFileId) ([]logs.StackTrace, error) {
+	encodedStackTraces, err := s.client.Get(ctx, getKey(logFileStackTracesNamespace, logFileId.String())).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, fmt.Errorf("unable to find stack traces of log file `%s`: %w", logFileId.String(), ErrNotFound)
+		}
+
+		return nil, fmt.Errorf("failed to get stack traces of log file `%s`: %w", logFileId.String(), err)
+	}
+
+	var stackTraces []logs.StackTrace
+	if err := json.Unmarshal(encodedStackTraces, &stackTraces); err != nil {
+		return nil, fmt.Errorf("failed to parse stack traces of log file `%s`: %w", logFileId.String(), err)
+	}
+
+	return stackTraces, nil
+}
+
+// GetLogFilesStackTraces returns the stack traces of all log files with a single request. The
+// stack traces of log files that haven't been indexed are nil.
+func (s *Service) GetLogFilesStackTraces(ctx context.Context, logFileIds []logs.LogFileId) ([][]logs.StackTrace, error) {
+	stackTraces := make([][]logs.StackTrace, len(logFileIds))
+	if len(logFileIds) == 0 {
+		return stackTraces, nil
+	}
+
+	keys := make([]string, len(logFileIds))
+	for i, logFileId := range logFileIds {
+		keys[i] = getKey(logFileStackTracesNamespace, logFileId.String())
+	}
+
+	values, err := s.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stack traces of log files: %w", err)
+	}
+
+	for i, value := range values {
+		encodedStackTraces, ok := value.(string)
+		if !ok {
+			continue
+		}
+
+		fileStackTraces := make([]logs.StackTrace, 0)
+		if err := json.Unmarshal([]byte(encodedStackTraces), &fileStackTraces); err != nil {
+			return nil, fmt.Errorf("failed to parse stack traces of log file `%s`: %w", logFileIds[i].String(), err)
+		}
+
+		stackTraces[i] = fileStackTraces
+	}
+
+	return stackTraces, nil
+}
+
+// GetLogFileMetadata returns the metadata of the log file that was computed after committing it.
+func (s *Service) GetLogFileMetadata(ctx context.Context, logFileId logs.LogFileId) (logs.LogFileMetadata, error) {
+	var metadata logs.LogFileMetadata
+
+	fields, err := s.client.HGetAll(ctx, getKey(logFilesNamespace, logFileId.String())).Result()
+	if err != nil {
+		return metadata, fmt.Errorf("failed to get metadata of log file `%s`: %w", logFileId.String(), err)
+	}
+
+	if len(fields) == 0 {
+		return metadata, fmt.Errorf("unable to find metadata of log file `%s`: %w", logFileId.String(), ErrNotFound)
+	}
+
+	if value, ok := fields[lineCountField]; ok {
+		metadata.LineCount, err = strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return metadata, fmt.Errorf("failed to parse line count of log file `%s`: %w", logFileId.String(), err)
+		}
+	}
+
+	metadata.Format = logs.Format(fields[formatField])
+	metadata.Name = fields[nameField]
+	metadata.ContentType = fields[contentTypeField]
+	metadata.Encoding = charset.Encoding(fields[encodingField])
+	metadata.Quarantine = fields[quarantineField]
+
+	if value, ok := fields[levelCountsField]; ok {
+		if err := json.Unmarshal([]byte(value), &metadata.LevelCounts); err != nil {
+			return metadata, fmt.Errorf("failed to parse level counts of log file `%s`: %w", logFileId.String(), err)
+		}
+	}
+
+	if value, ok := fields[redactionsField]; ok {
+		if err := json.Unmarshal([]byte(value), &metadata.Redactions); err != nil {
+			return metadata, fmt.Errorf("failed to parse redactions of log file `%s`: %w", logFileId.String(), err)
+		}
+	}
+
+	return metadata, nil
+}
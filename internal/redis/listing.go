@@ -0,0 +1,446 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"github.com/oklog/ulid/v2"
+	"github.com/redis/go-redis/v9"
+	"log/slog"
+	"simple-log-store/internal/logs"
+	"simple-log-store/internal/utils"
+	"sort"
+	"time"
+)
+
+// The secondary indexes of log bundles are sorted sets where every member has the score 0, so
+// they are ordered lexicographically by the log bundle ID, which is the same as ordering them
+// by the time they were created. Members aren't removed when the log bundle expires, instead
+// PruneBundleIndexes and QueryLogBundles remove members of log bundles that don't exist anymore.
+
+// sorted set of all log bundles
+const bundleIndexKey = "bundleIndex"
+
+// namespace contains a sorted set for every tag as `key=value` with the log bundles that have the tag
+const bundleIndexTagNamespace = "bundleIndexTag"
+
+// namespace contains a sorted set for every uploader with the log bundles uploaded by them
+const bundleIndexUploaderNamespace = "bundleIndexUploader"
+
+// sorted set of all uploaders, scored by the number of their log bundles
+const uploaderCountsKey = "uploaderCounts"
+
+// set of all tags as `key=value` that have an index
+const bundleIndexTagsKey = "bundleIndexTags"
+
+// maximum number of log bundles read while searching for a single page of matches
+const maxScannedBundles = 1000
+
+// number of log bundles read at once
+const bundleScanBatchSize = 100
+
+func getBundleIndexKeys(logBundle logs.LogBundle) []string {
+	keys := []string{bundleIndexKey}
+	for key, value := range logBundle.Tags {
+		keys = append(keys, getKey(bundleIndexTagNamespace, key+"="+value))
+	}
+
+	if logBundle.Uploader != "" {
+		keys = append(keys, getKey(bundleIndexUploaderNamespace, logBundle.Uploader))
+	}
+
+	return keys
+}
+
+// addToBundleIndexes adds a new log bundle to the secondary indexes, records its tags and
+// counts it for its uploader.
+func addToBundleIndexes(ctx context.Context, pipe redis.Pipeliner, logBundleId logs.LogBundleId, logBundle logs.LogBundle) {
+	for _, key := range getBundleIndexKeys(logBundle) {
+		pipe.ZAdd(ctx, key, redis.Z{Score: 0, Member: logBundleId.String()})
+	}
+
+	for key, value := range logBundle.Tags {
+		pipe.SAdd(ctx, bundleIndexTagsKey, key+"="+value)
+	}
+
+	if logBundle.Uploader != "" {
+		pipe.ZIncrBy(ctx, uploaderCountsKey, 1, logBundle.Uploader)
+	}
+}
+
+// removeFromBundleIndexes removes an existing log bundle from the secondary indexes.
+func removeFromBundleIndexes(ctx context.Context, pipe redis.Pipeliner, logBundleId logs.LogBundleId, logBundle logs.LogBundle) {
+	for _, key := range getBundleIndexKeys(logBundle) {
+		pipe.ZRem(ctx, key, logBundleId.String())
+	}
+
+	if logBundle.Uploader != "" {
+		pipe.ZIncrBy(ctx, uploaderCountsKey, -1, logBundle.Uploader)
+	}
+}
+
+// updateUploaderCounts sets the number of log bundles of the uploaders to the size of their
+// index, which corrects counts of log bundles that expired or were indexed more than once.
+func (s *Service) updateUploaderCounts(ctx context.Context, uploaders []string) error {
+	if len(uploaders) == 0 {
+		return nil
+	}
+
+	counts := make([]*redis.IntCmd, len(uploaders))
+	_, err := s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, uploader := range uploaders {
+			counts[i] = pipe.ZCard(ctx, getKey(bundleIndexUploaderNamespace, uploader))
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to count log bundles of uploaders: %w", err)
+	}
+
+	_, err = s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, uploader := range uploaders {
+			if count := counts[i].Val(); count != 0 {
+				pipe.ZAdd(ctx, uploaderCountsKey, redis.Z{Score: float64(count), Member: uploader})
+			} else {
+				pipe.ZRem(ctx, uploaderCountsKey, uploader)
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to update counts of uploaders: %w", err)
+	}
+
+	return nil
+}
+
+// IndexLogBundle adds an existing log bundle to the secondary indexes. Log bundles are indexed
+// when they are created, this is only required for log bundles created before the indexes existed.
+func (s *Service) IndexLogBundle(ctx context.Context, logBundleId logs.LogBundleId) error {
+	logBundle, err := s.GetLogBundleRecord(ctx, logBundleId)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		addToBundleIndexes(ctx, pipe, logBundleId, logBundle)
+		return nil
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to index log bundle `%s`: %w", logBundleId.String(), err)
+	}
+
+	if logBundle.Uploader != "" {
+		return s.updateUploaderCounts(ctx, []string{logBundle.Uploader})
+	}
+
+	return nil
+}
+
+// getTimeBound returns the smallest or largest possible ID of the time, depending on upper.
+func getTimeBound(timestamp time.Time, upper bool) string {
+	var id ulid.ULID
+	if timestamp.After(ulid.Time(0)) {
+		_ = id.SetTime(ulid.Timestamp(timestamp))
+	}
+
+	if upper {
+		for i := 6; i < len(id); i++ {
+			id[i] = 0xFF
+		}
+	}
+
+	return id.String()
+}
+
+// BundleQuery filters log bundles. Zero values don't filter.
+type BundleQuery struct {
+	// Tags must all be present with the same value.
+	Tags     map[string]string
+	Uploader string
+	// From and To are inclusive.
+	From    time.Time
+	To      time.Time
+	MinSize uint64
+	MaxSize uint64
+	// Cursor is the ID of the last log bundle of the previous page.
+	Cursor logs.LogBundleId
+	Limit  int
+}
+
+func (q BundleQuery) getIndexKey() string {
+	if q.Uploader != "" {
+		return getKey(bundleIndexUploaderNamespace, q.Uploader)
+	}
+
+	if len(q.Tags) != 0 {
+		keys := make([]string, 0, len(q.Tags))
+		for key := range q.Tags {
+			keys = append(keys, key)
+		}
+
+		sort.Strings(keys)
+		return getKey(bundleIndexTagNamespace, keys[0]+"="+q.Tags[keys[0]])
+	}
+
+	return bundleIndexKey
+}
+
+func (q BundleQuery) matches(logBundle logs.LogBundle) bool {
+	if q.Uploader != "" && logBundle.Uploader != q.Uploader {
+		return false
+	}
+
+	for key, value := range q.Tags {
+		if actual, ok := logBundle.Tags[key]; !ok || actual != value {
+			return false
+		}
+	}
+
+	if q.MinSize != 0 && logBundle.Size < q.MinSize {
+		return false
+	}
+
+	if q.MaxSize != 0 && logBundle.Size > q.MaxSize {
+		return false
+	}
+
+	return true
+}
+
+type ListedBundle struct {
+	LogBundleId logs.LogBundleId
+	logs.LogBundle
+}
+
+type BundlePage struct {
+	// Bundles are ordered from newest to oldest.
+	Bundles []ListedBundle
+	// NextCursor is the zero ID if there are no more log bundles.
+	NextCursor logs.LogBundleId
+}
+
+// QueryLogBundles returns a page of log bundles that match the query, using the most selective
+// secondary index. At most maxScannedBundles log bundles are read per page, so pages can
+// contain fewer matches than the limit even if there are more log bundles.
+func (s *Service) QueryLogBundles(ctx context.Context, query BundleQuery) (BundlePage, error) {
+	var page BundlePage
+	indexKey := query.getIndexKey()
+
+	maxBound := "+"
+	if !query.To.IsZero() {
+		maxBound = "[" + getTimeBound(query.To, true)
+	}
+
+	var zeroId logs.LogBundleId
+	if query.Cursor != zeroId && (maxBound == "+" || query.Cursor.String() <= maxBound[1:]) {
+		maxBound = "(" + query.Cursor.String()
+	}
+
+	minBound := "-"
+	if !query.From.IsZero() {
+		minBound = "[" + getTimeBound(query.From, false)
+	}
+
+	scanned := 0
+	for {
+		members, err := s.client.ZRevRangeByLex(ctx, indexKey, &redis.ZRangeBy{
+			Min:   minBound,
+			Max:   maxBound,
+			Count: bundleScanBatchSize,
+		}).Result()
+
+		if err != nil {
+			return page, fmt.Errorf("failed to read index `%s`: %w", indexKey, err)
+		}
+
+		if len(members) == 0 {
+			return page, nil
+		}
+
+		keys := make([]string, len(members))
+		for i, member := range members {
+			keys[i] = getKey(logBundlesNamespace, member)
+		}
+
+		values, err := s.client.MGet(ctx, keys...).Result()
+		if err != nil {
+			return page, fmt.Errorf("failed to get log bundles: %w", err)
+		}
+
+		var expired []any
+		for i, member := range members {
+			scanned += 1
+			maxBound = "(" + member
+
+			value, ok := values[i].(string)
+			if !ok {
+				expired = append(expired, member)
+				continue
+			}
+
+			logBundleId, err := logs.ParseId(member)
+			if err != nil {
+				s.logger.Warn("skipping index member with invalid log bundle ID", slog.String("member", member))
+				continue
+			}
+
+			logBundle, err := logs.DecodeBundle([]byte(value))
+			if err != nil {
+				s.logger.Warn("skipping log bundle that can't be decoded", slog.String("logBundleId", member), utils.ErrAttr(err))
+				continue
+			}
+
+			if !query.matches(logBundle) {
+				continue
+			}
+
+			page.Bundles = append(page.Bundles, ListedBundle{LogBundleId: logBundleId, LogBundle: logBundle})
+			if len(page.Bundles) >= query.Limit {
+				page.NextCursor = logBundleId
+				break
+			}
+		}
+
+		if len(expired) != 0 {
+			if err := s.client.ZRem(ctx, indexKey, expired...).Err(); err != nil {
+				s.logger.Warn("failed to remove expired log bundles from index", slog.String("indexKey", indexKey), utils.ErrAttr(err))
+			}
+		}
+
+		if page.NextCursor != zeroId || len(members) < bundleScanBatchSize {
+			return page, nil
+		}
+
+		if scanned >= maxScannedBundles {
+			page.NextCursor, _ = logs.ParseId(members[len(members)-1])
+			return page, nil
+		}
+	}
+}
+
+// PruneBundleIndexes removes log bundles that expired from the secondary indexes and returns
+// the number of removed members. Only log bundles older than the retention duration are
+// checked, log bundles are removed from the indexes immediately when they are deleted. The
+// indexes are found through the tags and the uploader counts, so the keyspace isn't scanned.
+func (s *Service) PruneBundleIndexes(ctx context.Context) (int, error) {
+	tags, err := s.client.SMembers(ctx, bundleIndexTagsKey).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get tags of bundle indexes: %w", err)
+	}
+
+	uploaders, err := s.client.ZRange(ctx, uploaderCountsKey, 0, -1).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get uploaders of bundle indexes: %w", err)
+	}
+
+	indexKeys := make([]string, 0, 1+len(tags)+len(uploaders))
+	indexKeys = append(indexKeys, bundleIndexKey)
+	for _, tag := range tags {
+		indexKeys = append(indexKeys, getKey(bundleIndexTagNamespace, tag))
+	}
+
+	for _, uploader := range uploaders {
+		indexKeys = append(indexKeys, getKey(bundleIndexUploaderNamespace, uploader))
+	}
+
+	maxBound := "(" + getTimeBound(time.Now().Add(-s.logRetentionDuration), false)
+	removed := 0
+	for _, indexKey := range indexKeys {
+		indexRemoved, err := s.pruneBundleIndex(ctx, indexKey, maxBound)
+		removed += indexRemoved
+		if err != nil {
+			return removed, err
+		}
+	}
+
+	if err := s.removeEmptyTags(ctx, tags); err != nil {
+		return removed, err
+	}
+
+	return removed, s.updateUploaderCounts(ctx, uploaders)
+}
+
+// pruneBundleIndex removes the members below maxBound whose log bundles don't exist anymore.
+func (s *Service) pruneBundleIndex(ctx context.Context, indexKey string, maxBound string) (int, error) {
+	members, err := s.client.ZRangeByLex(ctx, indexKey, &redis.ZRangeBy{Min: "-", Max: maxBound}).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read index `%s`: %w", indexKey, err)
+	}
+
+	removed := 0
+	for start := 0; start < len(members); start += scanCount {
+		batch := members[start:min(start+scanCount, len(members))]
+
+		cmds, err := s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+			for _, member := range batch {
+				pipe.Exists(ctx, getKey(logBundlesNamespace, member))
+			}
+
+			return nil
+		})
+
+		if err != nil {
+			return removed, fmt.Errorf("failed to check log bundles of index `%s`: %w", indexKey, err)
+		}
+
+		var expired []any
+		for i, cmd := range cmds {
+			if cmd.(*redis.IntCmd).Val() == 0 {
+				expired = append(expired, batch[i])
+			}
+		}
+
+		if len(expired) == 0 {
+			continue
+		}
+
+		if err := s.client.ZRem(ctx, indexKey, expired...).Err(); err != nil {
+			return removed, fmt.Errorf("failed to remove expired log bundles from index `%s`: %w", indexKey, err)
+		}
+
+		removed += len(expired)
+	}
+
+	return removed, nil
+}
+
+// removeEmptyTags forgets the tags whose index is empty, redis already removed the index itself.
+func (s *Service) removeEmptyTags(ctx context.Context, tags []string) error {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	cmds, err := s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, tag := range tags {
+			pipe.Exists(ctx, getKey(bundleIndexTagNamespace, tag))
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to check tag indexes: %w", err)
+	}
+
+	var empty []any
+	for i, cmd := range cmds {
+		if cmd.(*redis.IntCmd).Val() == 0 {
+			empty = append(empty, tags[i])
+		}
+	}
+
+	if len(empty) == 0 {
+		return nil
+	}
+
+	if err := s.client.SRem(ctx, bundleIndexTagsKey, empty...).Err(); err != nil {
+		return fmt.Errorf("failed to remove empty tag indexes: %w", err)
+	}
+
+	return nil
+}
@@ -14,7 +14,8 @@ import (
 // namespace contains all staged log files where the value is the staging time in UTC
 const stagedLogsNamespace = "stagedLogs"
 
-// namespace contains all log bundles where the value is a JSON array containing all referenced log file IDs
+// namespace contains all log bundles where the value is a JSON object with the referenced log file IDs and
+// the labels, older log bundles only contain the encoded log file IDs
 const logBundlesNamespace = "logBundles"
 
 func (s *Service) StageLogFile(ctx context.Context, id logs.LogFileId) error {
@@ -28,17 +29,24 @@ func (s *Service) StageLogFile(ctx context.Context, id logs.LogFileId) error {
 	return nil
 }
 
-func (s *Service) CreateLogBundle(ctx context.Context, logFileIds []logs.LogFileId) (logs.LogBundleId, error) {
+// CreateLogBundle stores the record of a new log bundle and adds it to the secondary indexes.
+func (s *Service) CreateLogBundle(ctx context.Context, logBundle logs.LogBundle) (logs.LogBundleId, error) {
 	bundleId := ulid.Make()
 
-	encoded, err := logs.EncodeIds(logFileIds)
+	encoded, err := logs.EncodeBundle(logBundle)
 	if err != nil {
-		s.logger.Error("failed to encode IDs", utils.ErrAttr(err))
+		s.logger.Error("failed to encode log bundle", utils.ErrAttr(err))
 		return bundleId, err
 	}
 
-	if err := s.set(logBundlesNamespace, bundleId.String(), encoded, ctx); err != nil {
-		return bundleId, err
+	_, err = s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, getKey(logBundlesNamespace, bundleId.String()), encoded, s.logRetentionDuration)
+		addToBundleIndexes(ctx, pipe, bundleId, logBundle)
+		return nil
+	})
+
+	if err != nil {
+		return bundleId, fmt.Errorf("failed to create log bundle `%s`: %w", bundleId.String(), err)
 	}
 
 	return bundleId, nil
@@ -47,20 +55,30 @@ func (s *Service) CreateLogBundle(ctx context.Context, logFileIds []logs.LogFile
 var ErrNotFound = errors.New("item not found")
 
 func (s *Service) GetLogBundle(ctx context.Context, logBundleId logs.LogBundleId) ([]logs.LogFileId, error) {
+	logBundle, err := s.GetLogBundleRecord(ctx, logBundleId)
+	if err != nil {
+		return nil, err
+	}
+
+	return logBundle.LogFileIds, nil
+}
+
+// GetLogBundleRecord returns the log file IDs and the labels of the log bundle.
+func (s *Service) GetLogBundleRecord(ctx context.Context, logBundleId logs.LogBundleId) (logs.LogBundle, error) {
 	cmd := s.client.Get(ctx, fmt.Sprintf("%s:%s", logBundlesNamespace, logBundleId.String()))
 	bytes, err := cmd.Bytes()
 	if err != nil {
 		if errors.Is(err, redis.Nil) {
-			return nil, fmt.Errorf("unable to find log bundle with ID `%s`: %w", logBundleId.String(), ErrNotFound)
+			return logs.LogBundle{}, fmt.Errorf("unable to find log bundle with ID `%s`: %w", logBundleId.String(), ErrNotFound)
 		}
 
-		return nil, fmt.Errorf("failed to get bytes for log bundle with ID `%s`: `%w`", logBundleId.String(), err)
+		return logs.LogBundle{}, fmt.Errorf("failed to get bytes for log bundle with ID `%s`: `%w`", logBundleId.String(), err)
 	}
 
-	logFileIds, err := logs.DecodeIds(bytes)
+	logBundle, err := logs.DecodeBundle(bytes)
 	if err != nil {
-		return nil, fmt.Errorf("failed to decode log file IDs for bundle `%s`: `%w`", logBundleId.String(), err)
+		return logs.LogBundle{}, fmt.Errorf("failed to decode log bundle `%s`: `%w`", logBundleId.String(), err)
 	}
 
-	return logFileIds, nil
+	return logBundle, nil
 }
@@ -0,0 +1,70 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/redis/go-redis/v9"
+	"simple-log-store/internal/metrics"
+	"time"
+)
+
+type redisMetrics struct {
+	commandDuration *prometheus.HistogramVec
+	commandErrors   *prometheus.CounterVec
+}
+
+func newRedisMetrics(registerer prometheus.Registerer) *redisMetrics {
+	factory := promauto.With(registerer)
+
+	return &redisMetrics{
+		commandDuration: factory.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: "redis",
+			Name:      "command_duration_seconds",
+			Help:      "Latency of redis commands.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"command"}),
+		commandErrors: factory.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: "redis",
+			Name:      "command_errors_total",
+			Help:      "Number of redis commands that failed. Missing keys are not counted as errors.",
+		}, []string{"command"}),
+	}
+}
+
+// metricsHook implements redis.Hook and records the latency and errors of every command.
+type metricsHook struct {
+	metrics *redisMetrics
+}
+
+func (h metricsHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (h metricsHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmd)
+		h.observe(cmd.Name(), start, err)
+		return err
+	}
+}
+
+func (h metricsHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmds)
+		h.observe("pipeline", start, err)
+		return err
+	}
+}
+
+func (h metricsHook) observe(command string, start time.Time, err error) {
+	h.metrics.commandDuration.WithLabelValues(command).Observe(time.Since(start).Seconds())
+	if err != nil && !errors.Is(err, redis.Nil) {
+		h.metrics.commandErrors.WithLabelValues(command).Inc()
+	}
+}
@@ -3,6 +3,7 @@ package redis
 import (
 	"context"
 	"fmt"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/redis/go-redis/v9"
 	"log/slog"
 	"simple-log-store/internal/config"
@@ -17,13 +18,14 @@ type Service struct {
 	logRetentionDuration time.Duration
 }
 
-func CreateService(appConfig *config.AppConfig, logger *slog.Logger) (*Service, error) {
+func CreateService(appConfig *config.AppConfig, logger *slog.Logger, registerer prometheus.Registerer) (*Service, error) {
 	opt, err := redis.ParseURL(appConfig.RedisConnectionString)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse redis URL: %w", err)
 	}
 
 	redisClient := redis.NewClient(opt)
+	redisClient.AddHook(metricsHook{metrics: newRedisMetrics(registerer)})
 
 	service := &Service{
 		logger:               logger.With(slog.String("service", "redis")),
@@ -34,8 +36,8 @@ func CreateService(appConfig *config.AppConfig, logger *slog.Logger) (*Service,
 	return service, nil
 }
 
-func (s *Service) Ping() error {
-	timeout, cancel := context.WithTimeout(context.Background(), time.Second*10)
+func (s *Service) Ping(ctx context.Context) error {
+	timeout, cancel := context.WithTimeout(ctx, time.Second*10)
 	defer cancel()
 
 	if err := s.client.Ping(timeout).Err(); err != nil {
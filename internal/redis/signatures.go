@@ -0,0 +1,304 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/oklog/ulid/v2"
+	"github.com/redis/go-redis/v9"
+	"simple-log-store/internal/logs"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// sorted set of all exception fingerprints, scored by the time they were last seen
+const signaturesKey = "signatures"
+
+// sorted set of all exception fingerprints, scored by the number of log bundles with the exception
+const signatureCountsKey = "signatureCounts"
+
+// namespace contains a hash for every fingerprint with the exception and message of the latest occurrence
+const signatureDetailsNamespace = "signatureDetails"
+
+// namespace contains a sorted set for every fingerprint with the log bundles, scored by the time of the log bundle
+const signatureBundlesNamespace = "signatureBundles"
+
+const (
+	exceptionField = "exception"
+	messageField   = "message"
+)
+
+// number of example log bundles returned per signature
+const signatureExampleCount = 5
+
+// AddSignatures records that the log bundle contains exceptions with the fingerprints of the stack traces.
+// The signatures don't expire, they are removed once all of their log bundles are gone, see PruneSignatures.
+func (s *Service) AddSignatures(ctx context.Context, logBundleId logs.LogBundleId, stackTraces []logs.StackTrace) error {
+	if len(stackTraces) == 0 {
+		return nil
+	}
+
+	bundleTime := float64(logBundleId.Time())
+	fingerprints := make([]string, len(stackTraces))
+
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, stackTrace := range stackTraces {
+			fingerprints[i] = stackTrace.Fingerprint
+			detailsKey := getKey(signatureDetailsNamespace, stackTrace.Fingerprint)
+			bundlesKey := getKey(signatureBundlesNamespace, stackTrace.Fingerprint)
+
+			pipe.ZAddArgs(ctx, signaturesKey, redis.ZAddArgs{
+				GT:      true,
+				Members: []redis.Z{{Score: bundleTime, Member: stackTrace.Fingerprint}},
+			})
+
+			pipe.HSet(ctx, detailsKey, exceptionField, stackTrace.Exception, messageField, stackTrace.Message)
+			pipe.Persist(ctx, detailsKey)
+			pipe.ZAdd(ctx, bundlesKey, redis.Z{Score: bundleTime, Member: logBundleId.String()})
+			pipe.Persist(ctx, bundlesKey)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to add signatures of log bundle `%s`: %w", logBundleId.String(), err)
+	}
+
+	return s.updateSignatureCounts(ctx, fingerprints)
+}
+
+// updateSignatureCounts stores the number of log bundles of the signatures and removes the
+// signatures without log bundles.
+func (s *Service) updateSignatureCounts(ctx context.Context, fingerprints []string) error {
+	if len(fingerprints) == 0 {
+		return nil
+	}
+
+	counts := make([]*redis.IntCmd, len(fingerprints))
+	_, err := s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, fingerprint := range fingerprints {
+			counts[i] = pipe.ZCard(ctx, getKey(signatureBundlesNamespace, fingerprint))
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to count log bundles of signatures: %w", err)
+	}
+
+	_, err = s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, fingerprint := range fingerprints {
+			count := counts[i].Val()
+			if count != 0 {
+				pipe.ZAdd(ctx, signatureCountsKey, redis.Z{Score: float64(count), Member: fingerprint})
+				continue
+			}
+
+			// empty sorted sets are removed by redis, so only the details are left
+			pipe.ZRem(ctx, signatureCountsKey, fingerprint)
+			pipe.ZRem(ctx, signaturesKey, fingerprint)
+			pipe.Del(ctx, getKey(signatureDetailsNamespace, fingerprint))
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to update counts of signatures: %w", err)
+	}
+
+	return nil
+}
+
+// removeSignatures removes the log bundle from the signatures of the stack traces of its log files
+// and returns their fingerprints, whose counts must be updated once the pipeline was executed.
+func (s *Service) removeSignatures(ctx context.Context, pipe redis.Pipeliner, logBundleId logs.LogBundleId, logFileIds []logs.LogFileId) ([]string, error) {
+	if len(logFileIds) == 0 {
+		return nil, nil
+	}
+
+	keys := make([]string, len(logFileIds))
+	for i, logFileId := range logFileIds {
+		keys[i] = getKey(logFileStackTracesNamespace, logFileId.String())
+	}
+
+	values, err := s.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stack traces of log bundle `%s`: %w", logBundleId.String(), err)
+	}
+
+	var fingerprints []string
+	for _, value := range values {
+		encodedStackTraces, ok := value.(string)
+		if !ok {
+			continue
+		}
+
+		var stackTraces []logs.StackTrace
+		if err := json.Unmarshal([]byte(encodedStackTraces), &stackTraces); err != nil {
+			continue
+		}
+
+		for _, stackTrace := range stackTraces {
+			pipe.ZRem(ctx, getKey(signatureBundlesNamespace, stackTrace.Fingerprint), logBundleId.String())
+			fingerprints = append(fingerprints, stackTrace.Fingerprint)
+		}
+	}
+
+	return fingerprints, nil
+}
+
+// PruneSignatures removes log bundles that don't exist anymore from the signatures and updates
+// their counts. Only log bundles older than the retention duration can have expired, so newer
+// ones aren't checked. Pinned and extended log bundles still exist and are kept. Returns the
+// number of removed occurrences.
+func (s *Service) PruneSignatures(ctx context.Context) (int, error) {
+	cutoff := strconv.FormatUint(ulid.Timestamp(time.Now().Add(-s.logRetentionDuration)), 10)
+
+	fingerprints, err := s.client.ZRange(ctx, signaturesKey, 0, -1).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get signatures: %w", err)
+	}
+
+	candidates := make([]*redis.StringSliceCmd, len(fingerprints))
+	_, err = s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, fingerprint := range fingerprints {
+			candidates[i] = pipe.ZRangeByScore(ctx, getKey(signatureBundlesNamespace, fingerprint), &redis.ZRangeBy{Min: "-inf", Max: "(" + cutoff})
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return 0, fmt.Errorf("failed to get old log bundles of signatures: %w", err)
+	}
+
+	exists := make(map[string]*redis.IntCmd)
+	_, err = s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, candidate := range candidates {
+			for _, member := range candidate.Val() {
+				if _, ok := exists[member]; !ok {
+					exists[member] = pipe.Exists(ctx, getKey(logBundlesNamespace, member))
+				}
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return 0, fmt.Errorf("failed to check log bundles of signatures: %w", err)
+	}
+
+	removed := 0
+	_, err = s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, candidate := range candidates {
+			for _, member := range candidate.Val() {
+				if exists[member].Val() == 0 {
+					pipe.ZRem(ctx, getKey(signatureBundlesNamespace, fingerprints[i]), member)
+					removed += 1
+				}
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return 0, fmt.Errorf("failed to remove expired log bundles of signatures: %w", err)
+	}
+
+	// all counts are updated, so signatures recorded before the counts existed are included
+	if err := s.updateSignatureCounts(ctx, fingerprints); err != nil {
+		return 0, err
+	}
+
+	return removed, nil
+}
+
+// ListSignatures returns up to limit signatures, ordered by the number of log bundles with the
+// exception.
+func (s *Service) ListSignatures(ctx context.Context, limit int) ([]logs.Signature, error) {
+	counts, err := s.client.ZRevRangeWithScores(ctx, signatureCountsKey, 0, int64(limit-1)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get signatures: %w", err)
+	}
+
+	type signatureCommands struct {
+		first    *redis.ZSliceCmd
+		examples *redis.ZSliceCmd
+		details  *redis.MapStringStringCmd
+	}
+
+	commands := make([]signatureCommands, len(counts))
+	_, err = s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, count := range counts {
+			fingerprint, _ := count.Member.(string)
+			bundlesKey := getKey(signatureBundlesNamespace, fingerprint)
+
+			commands[i] = signatureCommands{
+				first:    pipe.ZRangeWithScores(ctx, bundlesKey, 0, 0),
+				examples: pipe.ZRevRangeWithScores(ctx, bundlesKey, 0, signatureExampleCount-1),
+				details:  pipe.HGetAll(ctx, getKey(signatureDetailsNamespace, fingerprint)),
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("failed to get details of signatures: %w", err)
+	}
+
+	signatures := make([]logs.Signature, 0, len(counts))
+	for i, count := range counts {
+		fingerprint, _ := count.Member.(string)
+		examples := commands[i].examples.Val()
+		first := commands[i].first.Val()
+		if len(examples) == 0 || len(first) == 0 {
+			// all log bundles with the exception were deleted since the count was updated
+			continue
+		}
+
+		details := commands[i].details.Val()
+		signature := logs.Signature{
+			Fingerprint: fingerprint,
+			Exception:   details[exceptionField],
+			Message:     details[messageField],
+			BundleCount: int64(count.Score),
+			FirstSeen:   ulid.Time(uint64(first[0].Score)),
+			LastSeen:    ulid.Time(uint64(examples[0].Score)),
+		}
+
+		for _, example := range examples {
+			member, ok := example.Member.(string)
+			if !ok {
+				continue
+			}
+
+			logBundleId, err := logs.ParseId(member)
+			if err != nil {
+				s.logger.Warn("skipping invalid log bundle ID of signature")
+				continue
+			}
+
+			signature.ExampleBundles = append(signature.ExampleBundles, logBundleId)
+		}
+
+		signatures = append(signatures, signature)
+	}
+
+	sort.SliceStable(signatures, func(i, j int) bool {
+		if signatures[i].BundleCount != signatures[j].BundleCount {
+			return signatures[i].BundleCount > signatures[j].BundleCount
+		}
+
+		return signatures[i].LastSeen.After(signatures[j].LastSeen)
+	})
+
+	return signatures, nil
+}
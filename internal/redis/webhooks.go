@@ -0,0 +1,159 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/oklog/ulid/v2"
+	"github.com/redis/go-redis/v9"
+	"log/slog"
+	"simple-log-store/internal/logs"
+	"simple-log-store/internal/utils"
+	"time"
+)
+
+// namespace contains the JSON encoded record of every webhook delivery
+const webhookDeliveriesNamespace = "webhookDeliveries"
+
+// sorted set of all webhook deliveries, ordered by their ID like the bundle indexes
+const webhookDeliveryIndexKey = "webhookDeliveryIndex"
+
+// WebhookDelivery is the record of a request sent to a webhook for a single event. It expires
+// after the retention duration, counted from the creation of the delivery.
+type WebhookDelivery struct {
+	DeliveryId  ulid.ULID        `json:"deliveryId"`
+	LogBundleId logs.LogBundleId `json:"bundleId"`
+	Event       string           `json:"event"`
+	Url         string           `json:"url"`
+	Status      string           `json:"status"`
+	Attempts    int              `json:"attempts"`
+	StatusCode  int              `json:"statusCode,omitempty"`
+	Error       string           `json:"error,omitempty"`
+	UpdatedAt   time.Time        `json:"updatedAt"`
+}
+
+// SaveWebhookDelivery creates or updates the record of the delivery.
+func (s *Service) SaveWebhookDelivery(ctx context.Context, delivery WebhookDelivery) error {
+	encoded, err := json.Marshal(delivery)
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook delivery: %w", err)
+	}
+
+	deliveryId := delivery.DeliveryId.String()
+	// all deliveries older than the retention duration have expired
+	expiredBound := "(" + getTimeBound(time.Now().Add(-s.logRetentionDuration), false)
+
+	_, err = s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		key := getKey(webhookDeliveriesNamespace, deliveryId)
+		pipe.Set(ctx, key, encoded, 0)
+		pipe.ExpireAt(ctx, key, s.getExpiresAt(delivery.DeliveryId))
+		pipe.ZAdd(ctx, webhookDeliveryIndexKey, redis.Z{Score: 0, Member: deliveryId})
+		pipe.ZRemRangeByLex(ctx, webhookDeliveryIndexKey, "-", expiredBound)
+		return nil
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to save webhook delivery `%s`: %w", deliveryId, err)
+	}
+
+	return nil
+}
+
+// WebhookDeliveryQuery filters webhook deliveries. Zero values don't filter.
+type WebhookDeliveryQuery struct {
+	LogBundleId logs.LogBundleId
+	Status      string
+	// Cursor is the ID of the last delivery of the previous page.
+	Cursor ulid.ULID
+	Limit  int
+}
+
+func (q WebhookDeliveryQuery) matches(delivery WebhookDelivery) bool {
+	var zeroId ulid.ULID
+	if q.LogBundleId != zeroId && delivery.LogBundleId != q.LogBundleId {
+		return false
+	}
+
+	return q.Status == "" || delivery.Status == q.Status
+}
+
+type WebhookDeliveryPage struct {
+	// Deliveries are ordered from newest to oldest.
+	Deliveries []WebhookDelivery
+	// NextCursor is the zero ID if there are no more deliveries.
+	NextCursor ulid.ULID
+}
+
+// QueryWebhookDeliveries returns a page of webhook deliveries that match the query. Like
+// QueryLogBundles, at most maxScannedBundles deliveries are read per page.
+func (s *Service) QueryWebhookDeliveries(ctx context.Context, query WebhookDeliveryQuery) (WebhookDeliveryPage, error) {
+	var page WebhookDeliveryPage
+
+	var zeroId ulid.ULID
+	maxBound := "+"
+	if query.Cursor != zeroId {
+		maxBound = "(" + query.Cursor.String()
+	}
+
+	scanned := 0
+	for {
+		members, err := s.client.ZRevRangeByLex(ctx, webhookDeliveryIndexKey, &redis.ZRangeBy{
+			Min:   "-",
+			Max:   maxBound,
+			Count: bundleScanBatchSize,
+		}).Result()
+
+		if err != nil {
+			return page, fmt.Errorf("failed to read index `%s`: %w", webhookDeliveryIndexKey, err)
+		}
+
+		if len(members) == 0 {
+			return page, nil
+		}
+
+		keys := make([]string, len(members))
+		for i, member := range members {
+			keys[i] = getKey(webhookDeliveriesNamespace, member)
+		}
+
+		values, err := s.client.MGet(ctx, keys...).Result()
+		if err != nil {
+			return page, fmt.Errorf("failed to get webhook deliveries: %w", err)
+		}
+
+		for i, member := range members {
+			scanned += 1
+			maxBound = "(" + member
+
+			value, ok := values[i].(string)
+			if !ok {
+				continue
+			}
+
+			var delivery WebhookDelivery
+			if err := json.Unmarshal([]byte(value), &delivery); err != nil {
+				s.logger.Warn("skipping webhook delivery that can't be decoded", slog.String("deliveryId", member), utils.ErrAttr(err))
+				continue
+			}
+
+			if !query.matches(delivery) {
+				continue
+			}
+
+			page.Deliveries = append(page.Deliveries, delivery)
+			if len(page.Deliveries) >= query.Limit {
+				page.NextCursor = delivery.DeliveryId
+				break
+			}
+		}
+
+		if page.NextCursor != zeroId || len(members) < bundleScanBatchSize {
+			return page, nil
+		}
+
+		if scanned >= maxScannedBundles {
+			page.NextCursor, _ = ulid.Parse(members[len(members)-1])
+			return page, nil
+		}
+	}
+}
@@ -6,8 +6,12 @@ import (
 	"log/slog"
 )
 
+func getKey(namespace string, key string) string {
+	return fmt.Sprintf("%s:%s", namespace, key)
+}
+
 func (s *Service) set(namespace string, key string, value string, ctx context.Context) error {
-	redisKey := fmt.Sprintf("%s:%s", namespace, key)
+	redisKey := getKey(namespace, key)
 	if err := s.client.Set(ctx, redisKey, value, s.logRetentionDuration).Err(); err != nil {
 		s.logger.Error("failed to set value for key", slog.String("key", redisKey), slog.String("value", value))
 		return err
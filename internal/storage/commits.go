@@ -0,0 +1,114 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"simple-log-store/internal/logs"
+	"sync"
+)
+
+// ErrShuttingDown is returned for commits reserved after WaitForPendingCommits was called.
+var ErrShuttingDown = errors.New("the storage service is shutting down")
+
+// ShuttingDown returns true once WaitForPendingCommits was called, new commits are rejected
+// afterward.
+func (s *Service) ShuttingDown() bool {
+	s.pendingMutex.Lock()
+	defer s.pendingMutex.Unlock()
+
+	return s.closed
+}
+
+// PendingCommit is a commit reserved with ReserveCommit, the shutdown waits for it until it's
+// either started or canceled.
+type PendingCommit struct {
+	service    *Service
+	logFileIds []logs.LogFileId
+	once       sync.Once
+}
+
+// ReserveCommit reserves the commit of the log files, so the shutdown waits for it while the
+// bundle of the log files is created. Returns ErrShuttingDown once the shutdown started.
+func (s *Service) ReserveCommit(logFileIds []logs.LogFileId) (*PendingCommit, error) {
+	s.pendingMutex.Lock()
+	defer s.pendingMutex.Unlock()
+
+	if s.closed {
+		return nil, ErrShuttingDown
+	}
+
+	for _, logFileId := range logFileIds {
+		s.pendingLogFileIds[logFileId] = struct{}{}
+	}
+
+	s.pendingCommits.Add(1)
+	return &PendingCommit{service: s, logFileIds: logFileIds}, nil
+}
+
+// StoreLogFilesInBackground commits the log files in a new goroutine and calls afterCommit
+// with the result, even if no log files were committed. Use WaitForPendingCommits
+// to wait for all commits to finish. Does nothing if the commit was already started or
+// canceled.
+func (c *PendingCommit) StoreLogFilesInBackground(afterCommit func(result CommitResult)) {
+	c.once.Do(func() {
+		go func() {
+			defer c.release()
+
+			result := c.service.StoreLogFiles(c.logFileIds)
+			if afterCommit != nil {
+				afterCommit(result)
+			}
+		}()
+	})
+}
+
+// Cancel releases the commit without committing the log files, they remain in staging.
+// Does nothing if the commit was already started.
+func (c *PendingCommit) Cancel() {
+	c.once.Do(c.release)
+}
+
+func (c *PendingCommit) release() {
+	c.service.pendingMutex.Lock()
+	for _, logFileId := range c.logFileIds {
+		delete(c.service.pendingLogFileIds, logFileId)
+	}
+	c.service.pendingMutex.Unlock()
+
+	c.service.pendingCommits.Done()
+}
+
+// WaitForPendingCommits stops accepting new commits and blocks until all commits reserved
+// with ReserveCommit are finished or the context is done, in which case the
+// remaining log files are logged as abandoned.
+func (s *Service) WaitForPendingCommits(ctx context.Context) error {
+	s.pendingMutex.Lock()
+	s.closed = true
+	s.pendingMutex.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.pendingCommits.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		s.pendingMutex.Lock()
+		abandoned := make([]string, 0, len(s.pendingLogFileIds))
+		for logFileId := range s.pendingLogFileIds {
+			abandoned = append(abandoned, logFileId.String())
+		}
+		s.pendingMutex.Unlock()
+
+		if len(abandoned) == 0 {
+			return nil
+		}
+
+		s.logger.Error("abandoning pending commits, log files remain in staging", slog.Any("logFileIds", abandoned))
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,30 @@
+package storage
+
+import (
+	"mime"
+	"net/http"
+)
+
+// number of bytes considered by http.DetectContentType
+const sniffLength = 512
+
+// byteCounter discards the bytes written to it, but counts them.
+type byteCounter struct {
+	size uint64
+}
+
+func (c *byteCounter) Write(p []byte) (int, error) {
+	c.size += uint64(len(p))
+	return len(p), nil
+}
+
+// detectContentType returns the media type of a file with the given first bytes, without
+// parameters like the charset.
+func detectContentType(head []byte) string {
+	mediaType, _, err := mime.ParseMediaType(http.DetectContentType(head))
+	if err != nil {
+		return "application/octet-stream"
+	}
+
+	return mediaType
+}
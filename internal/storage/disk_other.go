@@ -0,0 +1,12 @@
+//go:build !(linux || darwin || freebsd)
+
+package storage
+
+import (
+	"errors"
+	"fmt"
+)
+
+func getFreeDiskSpace(directoryPath string) (uint64, error) {
+	return 0, fmt.Errorf("unable to get free disk space of `%s`: %w", directoryPath, errors.ErrUnsupported)
+}
@@ -0,0 +1,17 @@
+//go:build linux || darwin || freebsd
+
+package storage
+
+import (
+	"fmt"
+	"syscall"
+)
+
+func getFreeDiskSpace(directoryPath string) (uint64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(directoryPath, &stat); err != nil {
+		return 0, fmt.Errorf("failed to stat file system of `%s`: %w", directoryPath, err)
+	}
+
+	return uint64(stat.Bavail) * uint64(stat.Bsize), nil
+}
@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+type DirectoryHealth struct {
+	Path string
+	// FreeBytes is the number of bytes available to the process, only valid if HasFreeBytes is true.
+	FreeBytes    uint64
+	HasFreeBytes bool
+	Err          error
+}
+
+func (s *Service) CheckStagingDirectory() DirectoryHealth {
+	return checkDirectory(s.stagingPath)
+}
+
+func (s *Service) CheckStorageDirectory() DirectoryHealth {
+	return checkDirectory(s.storagePath)
+}
+
+func checkDirectory(directoryPath string) DirectoryHealth {
+	health := DirectoryHealth{
+		Path: directoryPath,
+	}
+
+	if err := writeTestFile(directoryPath); err != nil {
+		health.Err = err
+		return health
+	}
+
+	health.FreeBytes, health.HasFreeBytes, health.Err = getFreeBytes(directoryPath)
+	return health
+}
+
+// getFreeBytes returns the number of bytes available to the process and false if the platform
+// doesn't support it. Unlike checkDirectory it doesn't write anything.
+func getFreeBytes(directoryPath string) (uint64, bool, error) {
+	freeBytes, err := getFreeDiskSpace(directoryPath)
+	if err != nil {
+		if errors.Is(err, errors.ErrUnsupported) {
+			return 0, false, nil
+		}
+
+		return 0, false, err
+	}
+
+	return freeBytes, true, nil
+}
+
+// prefix of the test files written by the health check, which are removed right after writing
+const healthTestFilePrefix = ".health-"
+
+func writeTestFile(directoryPath string) error {
+	file, err := os.CreateTemp(directoryPath, healthTestFilePrefix+"*")
+	if err != nil {
+		return fmt.Errorf("failed to create test file in `%s`: %w", directoryPath, err)
+	}
+
+	filePath := file.Name()
+	_, writeErr := file.Write([]byte{0})
+	closeErr := file.Close()
+	removeErr := os.Remove(filePath)
+
+	if err := errors.Join(writeErr, closeErr, removeErr); err != nil {
+		return fmt.Errorf("failed to write test file `%s`: %w", filePath, err)
+	}
+
+	return nil
+}
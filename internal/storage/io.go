@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"path/filepath"
+	"simple-log-store/internal/utils"
 )
 
 func (s *Service) createDirectory(directoryPath string) error {
@@ -30,6 +32,48 @@ func (s *Service) createDirectory(directoryPath string) error {
 	return nil
 }
 
+type DirectoryUsage struct {
+	Path      string
+	FileCount int
+	Bytes     int64
+	// FreeBytes is the number of bytes available to the process, only valid if HasFreeBytes is true.
+	FreeBytes    uint64
+	HasFreeBytes bool
+}
+
+func (s *Service) getDirectoryUsage(directoryPath string) (DirectoryUsage, error) {
+	usage := DirectoryUsage{
+		Path: directoryPath,
+	}
+
+	directoryEntries, err := os.ReadDir(directoryPath)
+	if err != nil {
+		return usage, fmt.Errorf("failed to read directory `%s`: %w", directoryPath, err)
+	}
+
+	for _, directoryEntry := range directoryEntries {
+		if directoryEntry.IsDir() {
+			continue
+		}
+
+		fileInfo, err := directoryEntry.Info()
+		if err != nil {
+			s.logger.Error("failed to get info of file", slog.String("filePath", filepath.Join(directoryPath, directoryEntry.Name())), utils.ErrAttr(err))
+			continue
+		}
+
+		usage.FileCount += 1
+		usage.Bytes += fileInfo.Size()
+	}
+
+	usage.FreeBytes, usage.HasFreeBytes, err = getFreeBytes(directoryPath)
+	if err != nil {
+		s.logger.Error("failed to get free bytes of directory", slog.String("directoryPath", directoryPath), utils.ErrAttr(err))
+	}
+
+	return usage, nil
+}
+
 type moveFileFunc func(string, string) error
 
 func noMove(_ string, _ string) error {
@@ -0,0 +1,118 @@
+package storage
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"simple-log-store/internal/charset"
+	"simple-log-store/internal/logs"
+)
+
+type LogFileLine struct {
+	// Number is the 1-based line number.
+	Number uint64
+	Text   string
+}
+
+type LogFileLines struct {
+	Lines []LogFileLine
+	// TotalLines is the number of lines in the file.
+	TotalLines uint64
+	// MatchingLines is the number of lines accepted by the filter.
+	MatchingLines uint64
+}
+
+// LineFilter decides whether the line with the 1-based line number is included. The line
+// is only valid until the filter returns.
+type LineFilter func(lineNumber uint64, line []byte) bool
+
+// ReadLogFileLines skips the first skip lines accepted by the filter and returns up to count
+// of the following lines. A nil filter accepts every line. The total number of lines and the
+// number of lines accepted by the filter are returned as well. The lines are decoded from the
+// encoding to UTF-8.
+func (s *Service) ReadLogFileLines(logFileId logs.LogFileId, encoding charset.Encoding, skip uint64, count int, filter LineFilter) (LogFileLines, error) {
+	var res LogFileLines
+
+	file, err := s.OpenDecodedLogFile(logFileId, encoding)
+	if err != nil {
+		return res, err
+	}
+
+	defer func() {
+		_ = file.Close()
+	}()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), file.MaxLineLength)
+
+	for scanner.Scan() {
+		res.TotalLines += 1
+		if filter != nil && !filter(res.TotalLines, scanner.Bytes()) {
+			continue
+		}
+
+		res.MatchingLines += 1
+		if res.MatchingLines <= skip || len(res.Lines) >= count {
+			continue
+		}
+
+		res.Lines = append(res.Lines, LogFileLine{
+			Number: res.TotalLines,
+			Text:   scanner.Text(),
+		})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return res, fmt.Errorf("failed to read lines of log file `%s`: %w", logFileId.String(), err)
+	}
+
+	return res, nil
+}
+
+// WriteLogFileLines writes up to count lines of the log file to w, decoded from the encoding to
+// UTF-8. Reading starts at the byte offset of a line in the original bytes and skips the first
+// skip lines, so only the lines before the range are scanned. Newlines are kept.
+func (s *Service) WriteLogFileLines(w io.Writer, logFileId logs.LogFileId, encoding charset.Encoding, offset int64, skip uint64, count uint64) error {
+	file, err := s.OpenLogFile(logFileId)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = file.Close()
+	}()
+
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to seek to offset `%d` of log file `%s`: %w", offset, logFileId.String(), err)
+	}
+
+	reader := bufio.NewReader(charset.NewReader(file, encoding))
+	for lineNumber := uint64(0); lineNumber < skip+count; lineNumber++ {
+		// lines are read in parts if they are larger than the buffer
+		for {
+			line, err := reader.ReadSlice('\n')
+			if lineNumber >= skip && len(line) != 0 {
+				if _, err := w.Write(line); err != nil {
+					return err
+				}
+			}
+
+			if errors.Is(err, bufio.ErrBufferFull) {
+				continue
+			}
+
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+
+			if err != nil {
+				return fmt.Errorf("failed to read lines of log file `%s`: %w", logFileId.String(), err)
+			}
+
+			break
+		}
+	}
+
+	return nil
+}
@@ -1,14 +1,20 @@
 package storage
 
 import (
-	"errors"
+	"bufio"
+	"context"
 	"fmt"
 	"io"
+	"io/fs"
 	"log/slog"
 	"os"
 	"path/filepath"
+	"simple-log-store/internal/charset"
+	"simple-log-store/internal/ingest"
 	"simple-log-store/internal/logs"
+	"simple-log-store/internal/redact"
 	"simple-log-store/internal/utils"
+	"strings"
 	"time"
 )
 
@@ -29,7 +35,31 @@ func (f FileTooLarge) Error() string {
 	return fmt.Sprintf("expected file size to be less than `%d` bytes but received `%d` bytes", f.Limit, f.Actual)
 }
 
-func (s *Service) StageLogFile(id logs.LogFileId, reader io.Reader, maxFileSize uint64) error {
+// UnredactableFile is returned for binaries while log files are redacted, the redaction
+// would corrupt them.
+type UnredactableFile struct {
+	ContentType string
+}
+
+func (u UnredactableFile) Error() string {
+	return fmt.Sprintf("files of type `%s` can't be redacted", u.ContentType)
+}
+
+// StagedLogFile describes a log file that was written to the staging directory.
+type StagedLogFile struct {
+	// Size is the number of bytes written to the staging directory, after redaction.
+	Size       uint64
+	Redactions logs.RedactionCounts
+	// ContentType is the media type detected from the first bytes, before redaction.
+	ContentType string
+	// Encoding is the character encoding of the redacted log file, it's unknown for binaries.
+	Encoding charset.Encoding
+}
+
+// StageLogFile writes the log file to the staging directory. Unless unredacted log files are
+// allowed, secrets and personal data are redacted before they reach the disk and the number
+// of redacted values is returned. Binaries are rejected with UnredactableFile in that case.
+func (s *Service) StageLogFile(id logs.LogFileId, reader io.Reader, maxFileSize uint64) (StagedLogFile, error) {
 	logFilePath := s.getStagingPath(id)
 	logger := s.logger.With(slog.String("logFilePath", logFilePath), slog.String("logFileId", id.String()))
 
@@ -59,6 +89,20 @@ func (s *Service) StageLogFile(id logs.LogFileId, reader io.Reader, maxFileSize
 
 	logger.Info("begin staging log file")
 
+	// reading one byte more than allowed is the only way to know whether the file is too large
+	var read byteCounter
+	bufferedReader := bufio.NewReader(io.TeeReader(io.LimitReader(reader, int64(maxFileSize)+1), &read))
+	var wrappedReader io.Reader = bufferedReader
+
+	// errors are returned again by the next read
+	head, _ := bufferedReader.Peek(sniffLength)
+	contentType := detectContentType(head)
+	isText := strings.HasPrefix(contentType, "text/")
+	if s.redactor != nil && !isText {
+		logger.Error("refusing to stage binary that can't be redacted", slog.String("contentType", contentType))
+		return StagedLogFile{}, UnredactableFile{ContentType: contentType}
+	}
+
 	file, err := os.OpenFile(logFilePath, os.O_CREATE|os.O_RDWR|os.O_EXCL, s.filePermissions)
 	defer func(file *os.File) {
 		_ = file.Close()
@@ -67,36 +111,138 @@ func (s *Service) StageLogFile(id logs.LogFileId, reader io.Reader, maxFileSize
 	if err != nil {
 		*shouldCleanup = true
 		logger.Error("failed to open file for writing")
-		return fmt.Errorf("failed to open file for writing: %w", err)
+		return StagedLogFile{}, fmt.Errorf("failed to open file for writing: %w", err)
+	}
+
+	var written byteCounter
+	encodingDetector := charset.NewDetector()
+	var writer io.Writer = io.MultiWriter(file, encodingDetector, &written)
+	var redactionWriter *redact.Writer
+	if s.redactor != nil {
+		// the detectors only match UTF-8, so UTF-16 is decoded before and encoded again after
+		// redacting it, which also keeps the byte order mark
+		if encoding := charset.ByteOrderMark(head); encoding != charset.Unknown {
+			wrappedReader = charset.NewReader(wrappedReader, encoding)
+			writer = charset.NewWriter(writer, encoding)
+		}
+
+		redactionWriter = s.redactor.NewWriter(writer)
+		writer = redactionWriter
+	}
+
+	_, err = io.Copy(writer, wrappedReader)
+	if err == nil && redactionWriter != nil {
+		err = redactionWriter.Close()
 	}
 
-	wrappedReader := io.LimitReader(reader, int64(maxFileSize))
-	n, err := file.ReadFrom(wrappedReader)
 	if err != nil {
 		*shouldCleanup = true
-		if errors.Is(err, io.EOF) {
-			logger.Error("file too big to upload", slog.Int64("bytes", n))
-			return FileTooLarge{
-				Limit:  maxFileSize,
-				Actual: uint64(n),
-			}
+		return StagedLogFile{}, fmt.Errorf("unexpected error while writing to file: %w", err)
+	}
+
+	if read.size > maxFileSize {
+		*shouldCleanup = true
+		logger.Error("file too big to upload", slog.Uint64("bytes", read.size))
+		return StagedLogFile{}, FileTooLarge{
+			Limit:  maxFileSize,
+			Actual: read.size,
 		}
+	}
 
-		return fmt.Errorf("unexpected error while writing to file: %w", err)
+	var redactions logs.RedactionCounts
+	if redactionWriter != nil {
+		redactions = redactionWriter.Counts()
 	}
 
-	logger.Info("successfully staged log file", slog.Int64("bytes", n))
-	return nil
+	s.metrics.stagedFiles.Inc()
+	s.metrics.stagedBytes.Add(float64(written.size))
+
+	encoding := charset.Unknown
+	if isText {
+		encoding = encodingDetector.Encoding()
+	}
+
+	logger.Info("successfully staged log file", slog.Uint64("bytes", written.size), slog.Uint64("redactions", redactions.Total()), slog.String("contentType", contentType), slog.String("encoding", string(encoding)))
+	return StagedLogFile{
+		Size:        written.size,
+		Redactions:  redactions,
+		ContentType: contentType,
+		Encoding:    encoding,
+	}, nil
 }
 
-func (s *Service) StoreLogFiles(logFileIds []logs.LogFileId) {
+// CommitResult is the outcome of committing log files.
+type CommitResult struct {
+	Committed []logs.LogFileId
+	// Quarantined contains the reason for every log file rejected by an ingestion hook.
+	Quarantined map[logs.LogFileId]string
+	// Uninspected contains the error for every log file that the ingestion hooks failed to
+	// inspect, like when a scanner is unavailable.
+	Uninspected map[logs.LogFileId]string
+}
+
+// StoreLogFiles runs the ingestion hooks on the log files and moves them from the staging
+// directory to the storage directory, or to the quarantine directory if they are rejected.
+// Log files that are neither committed nor quarantined remain in the staging directory.
+func (s *Service) StoreLogFiles(logFileIds []logs.LogFileId) CommitResult {
+	res := CommitResult{
+		Committed:   make([]logs.LogFileId, 0, len(logFileIds)),
+		Quarantined: make(map[logs.LogFileId]string),
+		Uninspected: make(map[logs.LogFileId]string),
+	}
+
 	for _, logFileId := range logFileIds {
 		stagingPath := s.getStagingPath(logFileId)
 		storagePath := s.getStoragePath(logFileId)
 
+		if s.ingestPipeline != nil {
+			result, err := s.ingestPipeline.Inspect(context.Background(), stagingPath)
+			if err != nil {
+				s.metrics.commitFailures.Inc()
+				s.logger.Error("failed to inspect log file", slog.String("stagingPath", stagingPath), utils.ErrAttr(err))
+				res.Uninspected[logFileId] = err.Error()
+				continue
+			}
+
+			if result.Rejected {
+				s.quarantineLogFile(logFileId, result)
+				res.Quarantined[logFileId] = result.String()
+				continue
+			}
+		}
+
 		if err := s.moveFileFunc(stagingPath, storagePath); err != nil {
+			s.metrics.commitFailures.Inc()
 			s.logger.Error("failed to store log file", slog.String("stagingPath", stagingPath), slog.String("storagePath", storagePath), utils.ErrAttr(err))
+			continue
+		}
+
+		res.Committed = append(res.Committed, logFileId)
+		s.metrics.committedFiles.Inc()
+
+		fileInfo, err := os.Stat(storagePath)
+		if err != nil {
+			s.logger.Error("failed to stat stored log file", slog.String("storagePath", storagePath), utils.ErrAttr(err))
+			continue
 		}
+
+		s.metrics.committedBytes.Add(float64(fileInfo.Size()))
+		s.metrics.storageBytes.Add(float64(fileInfo.Size()))
+	}
+
+	return res
+}
+
+// quarantineLogFile moves a rejected log file out of the staging directory. It's kept for
+// the retention duration, so it can be examined by an administrator.
+func (s *Service) quarantineLogFile(logFileId logs.LogFileId, result ingest.Result) {
+	stagingPath := s.getStagingPath(logFileId)
+	quarantinePath := filepath.Join(s.quarantinePath, logFileId.String())
+	s.metrics.quarantinedFiles.Inc()
+
+	s.logger.Warn("quarantining log file rejected by ingestion hook", slog.String("logFileId", logFileId.String()), slog.String("hook", result.Hook), slog.String("reason", result.Reason))
+	if err := os.Rename(stagingPath, quarantinePath); err != nil {
+		s.logger.Error("failed to move log file to quarantine, it remains in staging", slog.String("stagingPath", stagingPath), slog.String("quarantinePath", quarantinePath), utils.ErrAttr(err))
 	}
 }
 
@@ -113,20 +259,128 @@ func (s *Service) OpenLogFile(logFileId logs.LogFileId) (*os.File, error) {
 	return file, nil
 }
 
+// maxDecodingGrowth is the factor by which decoding can grow a log file, Windows-1252
+// characters like `€` and UTF-16 code units take up to three bytes in UTF-8.
+const maxDecodingGrowth = 3
+
+// DecodedLogFile is a log file decoded to UTF-8.
+type DecodedLogFile struct {
+	io.Reader
+	io.Closer
+	// MaxLineLength is the upper limit of the length of a decoded line, a single line can be as
+	// large as the entire decoded log file.
+	MaxLineLength int
+}
+
+// OpenDecodedLogFile opens the log file and decodes it from its encoding to UTF-8.
+func (s *Service) OpenDecodedLogFile(logFileId logs.LogFileId, encoding charset.Encoding) (*DecodedLogFile, error) {
+	file, err := s.OpenLogFile(logFileId)
+	if err != nil {
+		return nil, err
+	}
+
+	fileInfo, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return nil, fmt.Errorf("failed to stat log file `%s`: %w", logFileId.String(), err)
+	}
+
+	// the size on disk is used, because redaction can make log files larger than the limit
+	maxLineLength := int(fileInfo.Size())
+	if !encoding.IsUtf8() {
+		maxLineLength *= maxDecodingGrowth
+	}
+
+	return &DecodedLogFile{
+		Reader:        charset.NewReader(file, encoding),
+		Closer:        file,
+		MaxLineLength: maxLineLength + 1,
+	}, nil
+}
+
+type LogFileInfo struct {
+	Id               logs.LogFileId
+	Size             int64
+	ModificationTime time.Time
+}
+
+func (s *Service) StatLogFile(logFileId logs.LogFileId) (LogFileInfo, error) {
+	fileInfo, err := os.Stat(s.getStoragePath(logFileId))
+	if err != nil {
+		return LogFileInfo{}, err
+	}
+
+	return LogFileInfo{
+		Id:               logFileId,
+		Size:             fileInfo.Size(),
+		ModificationTime: fileInfo.ModTime(),
+	}, nil
+}
+
+// ListLogFiles returns all log files in the storage directory. The second return value
+// contains the names of all files that aren't log files, except for the quarantine directory
+// and the test files of running health checks.
+func (s *Service) ListLogFiles() ([]LogFileInfo, []string, error) {
+	directoryEntries, err := os.ReadDir(s.storagePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read directory `%s`: %w", s.storagePath, err)
+	}
+
+	var res []LogFileInfo
+	var unknown []string
+
+	for _, directoryEntry := range directoryEntries {
+		if s.isQuarantineDirectory(directoryEntry) || strings.HasPrefix(directoryEntry.Name(), healthTestFilePrefix) {
+			continue
+		}
+
+		logFileId, err := logs.ParseId(directoryEntry.Name())
+		if err != nil || directoryEntry.IsDir() {
+			unknown = append(unknown, directoryEntry.Name())
+			continue
+		}
+
+		fileInfo, err := directoryEntry.Info()
+		if err != nil {
+			s.logger.Error("failed to get info of file", slog.String("fileName", directoryEntry.Name()), utils.ErrAttr(err))
+			continue
+		}
+
+		res = append(res, LogFileInfo{
+			Id:               logFileId,
+			Size:             fileInfo.Size(),
+			ModificationTime: fileInfo.ModTime(),
+		})
+	}
+
+	return res, unknown, nil
+}
+
 func (s *Service) DeleteLogFile(logFileId logs.LogFileId) error {
 	logFilePath := s.getStoragePath(logFileId)
 
+	fileInfo, err := os.Stat(logFilePath)
+	if err != nil {
+		s.logger.Error("failed to stat log file", slog.String("logFilePath", logFilePath), utils.ErrAttr(err))
+		return err
+	}
+
 	if err := os.Remove(logFilePath); err != nil {
 		s.logger.Error("failed to remove log file", slog.String("logFilePath", logFilePath), utils.ErrAttr(err))
 		return err
 	}
 
+	s.metrics.removedFiles.Inc()
+	s.metrics.storageBytes.Sub(float64(fileInfo.Size()))
 	return nil
 }
 
-func (s *Service) RemoveOldLogFiles(before time.Time) error {
+// RemoveOldLogFiles removes all log files that were last modified before the given time,
+// except for the pinned log files. Returns the number of removed log files.
+func (s *Service) RemoveOldLogFiles(before time.Time, pinnedLogFileIds map[logs.LogFileId]struct{}) (int, error) {
 	directoryPath := s.storagePath
 
+	s.metrics.cleanupRuns.Inc()
 	s.logger.Info("begin removing old log files")
 	defer func(logger *slog.Logger) {
 		logger.Info("finished removing old log files")
@@ -136,11 +390,16 @@ func (s *Service) RemoveOldLogFiles(before time.Time) error {
 	if err != nil {
 		s.logger.Error("error while reading directory", slog.String("directoryPath", directoryPath), utils.ErrAttr(err))
 		if len(directoryEntries) == 0 {
-			return err
+			return 0, err
 		}
 	}
 
+	removedCount := 0
 	for _, directoryEntry := range directoryEntries {
+		if s.isQuarantineDirectory(directoryEntry) {
+			continue
+		}
+
 		filePath := filepath.Join(directoryPath, directoryEntry.Name())
 		fileInfo, err := directoryEntry.Info()
 		if err != nil {
@@ -153,12 +412,60 @@ func (s *Service) RemoveOldLogFiles(before time.Time) error {
 			continue
 		}
 
+		if logFileId, err := logs.ParseId(directoryEntry.Name()); err == nil {
+			if _, isPinned := pinnedLogFileIds[logFileId]; isPinned {
+				continue
+			}
+		}
+
 		s.logger.Info("removing old log file", slog.String("filePath", filePath))
 		if err := os.Remove(filePath); err != nil {
 			s.logger.Error("failed to remove old log file", slog.String("filePath", filePath), utils.ErrAttr(err))
 			continue
 		}
+
+		removedCount += 1
+		s.metrics.removedFiles.Inc()
+		s.metrics.storageBytes.Sub(float64(fileInfo.Size()))
 	}
 
-	return nil
+	return removedCount, nil
+}
+
+// isQuarantineDirectory returns true if the entry of the storage directory is the quarantine
+// directory, which is inside of the storage directory if it's also the staging directory.
+func (s *Service) isQuarantineDirectory(directoryEntry fs.DirEntry) bool {
+	return directoryEntry.IsDir() && filepath.Join(s.storagePath, directoryEntry.Name()) == filepath.Clean(s.quarantinePath)
+}
+
+// RemoveOldQuarantinedFiles removes all quarantined log files that were last modified before
+// the given time. Returns the number of removed log files.
+func (s *Service) RemoveOldQuarantinedFiles(before time.Time) (int, error) {
+	if s.ingestPipeline == nil {
+		return 0, nil
+	}
+
+	directoryEntries, err := os.ReadDir(s.quarantinePath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read directory `%s`: %w", s.quarantinePath, err)
+	}
+
+	removedCount := 0
+	for _, directoryEntry := range directoryEntries {
+		filePath := filepath.Join(s.quarantinePath, directoryEntry.Name())
+		fileInfo, err := directoryEntry.Info()
+		if err != nil || !fileInfo.ModTime().Before(before) {
+			continue
+		}
+
+		s.logger.Info("removing old quarantined log file", slog.String("filePath", filePath))
+		if err := os.Remove(filePath); err != nil {
+			s.logger.Error("failed to remove old quarantined log file", slog.String("filePath", filePath), utils.ErrAttr(err))
+			continue
+		}
+
+		removedCount += 1
+	}
+
+	return removedCount, nil
 }
@@ -0,0 +1,199 @@
+package storage
+
+import (
+	"bytes"
+	"github.com/oklog/ulid/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"simple-log-store/internal/charset"
+	"simple-log-store/internal/redact"
+	"testing"
+	"time"
+)
+
+func createTestService(t *testing.T) *Service {
+	t.Helper()
+
+	detectors, err := redact.GetBuiltinDetectors(redact.BuiltinDetectorNames())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &Service{
+		logger:          slog.New(slog.NewTextHandler(io.Discard, nil)),
+		metrics:         newStorageMetrics(prometheus.NewRegistry()),
+		stagingPath:     t.TempDir(),
+		redactor:        redact.New(detectors),
+		filePermissions: defaultFilePermissions,
+	}
+}
+
+func encode(t *testing.T, text string, encoding charset.Encoding) []byte {
+	t.Helper()
+
+	var buffer bytes.Buffer
+	if encoding == charset.Windows1252 {
+		for _, r := range text {
+			buffer.WriteByte(byte(r))
+		}
+
+		return buffer.Bytes()
+	}
+
+	if _, err := io.WriteString(charset.NewWriter(&buffer, encoding), text); err != nil {
+		t.Fatal(err)
+	}
+
+	return buffer.Bytes()
+}
+
+func TestStageLogFileRedactsEveryEncoding(t *testing.T) {
+	const input = "user alice@example.com logged in from 10.0.0.1\nday über café\n"
+	const expected = "user [REDACTED:email] logged in from [REDACTED:ip]\nday über café\n"
+
+	for _, encoding := range []charset.Encoding{charset.Utf8, charset.Utf16LE, charset.Utf16BE, charset.Windows1252} {
+		t.Run(string(encoding), func(t *testing.T) {
+			service := createTestService(t)
+			id := ulid.Make()
+			data := encode(t, input, encoding)
+
+			staged, err := service.StageLogFile(id, bytes.NewReader(data), uint64(len(data)))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if staged.Encoding != encoding {
+				t.Errorf("expected encoding %q, got %q", encoding, staged.Encoding)
+			}
+
+			if staged.Redactions["email"] != 1 || staged.Redactions["ip"] != 1 {
+				t.Errorf("expected one email and one ip redaction, got %v", staged.Redactions)
+			}
+
+			stored, err := os.ReadFile(service.getStagingPath(id))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			want := encode(t, expected, encoding)
+			if !bytes.Equal(stored, want) {
+				t.Errorf("expected staged file %q, got %q", want, stored)
+			}
+
+			// the size is the one of the redacted file
+			if staged.Size != uint64(len(want)) {
+				t.Errorf("expected size %d, got %d", len(want), staged.Size)
+			}
+		})
+	}
+}
+
+func TestStageLogFileTooLarge(t *testing.T) {
+	service := createTestService(t)
+	data := encode(t, "0123456789\n", charset.Utf16LE)
+
+	_, err := service.StageLogFile(ulid.Make(), bytes.NewReader(data), uint64(len(data)-1))
+	if _, ok := err.(FileTooLarge); !ok {
+		t.Fatalf("expected FileTooLarge, got %v", err)
+	}
+}
+
+func TestStageLogFileBinary(t *testing.T) {
+	// the redaction of an email address would change the length of the archive
+	data := append([]byte("PK\x03\x04\x14\x00\x00\x00"), "alice@example.com\n\x00\x01\x02"...)
+
+	service := createTestService(t)
+	id := ulid.Make()
+
+	_, err := service.StageLogFile(id, bytes.NewReader(data), uint64(len(data)))
+	if _, ok := err.(UnredactableFile); !ok {
+		t.Fatalf("expected UnredactableFile, got %v", err)
+	}
+
+	if _, err := os.Stat(service.getStagingPath(id)); !os.IsNotExist(err) {
+		t.Errorf("expected no staged file, got %v", err)
+	}
+
+	// binaries are stored untouched if unredacted log files are allowed
+	service.redactor = nil
+	staged, err := service.StageLogFile(id, bytes.NewReader(data), uint64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if staged.ContentType != "application/zip" || staged.Encoding != charset.Unknown || staged.Size != uint64(len(data)) {
+		t.Errorf("unexpected staged binary %+v", staged)
+	}
+
+	stored, err := os.ReadFile(service.getStagingPath(id))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(stored, data) {
+		t.Errorf("expected staged file %q, got %q", data, stored)
+	}
+}
+
+func TestQuarantineDirectoryInSharedStorage(t *testing.T) {
+	service := createTestService(t)
+	service.storagePath = service.stagingPath
+	service.quarantinePath = filepath.Join(service.stagingPath, quarantineDirectoryName)
+
+	if err := os.Mkdir(service.quarantinePath, 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	id := ulid.Make()
+	if err := os.WriteFile(service.getStoragePath(id), []byte("line\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	logFileInfos, unknown, err := service.ListLogFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(logFileInfos) != 1 || logFileInfos[0].Id != id || len(unknown) != 0 {
+		t.Errorf("expected only log file `%s`, got %v and unknown files %v", id.String(), logFileInfos, unknown)
+	}
+
+	removedCount, err := service.RemoveOldLogFiles(time.Now().Add(time.Hour), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if removedCount != 1 {
+		t.Errorf("expected 1 removed log file, got %d", removedCount)
+	}
+
+	if _, err := os.Stat(service.quarantinePath); err != nil {
+		t.Errorf("expected quarantine directory to remain, got %v", err)
+	}
+}
+
+func TestListLogFilesSkipsHealthTestFiles(t *testing.T) {
+	service := createTestService(t)
+	service.storagePath = t.TempDir()
+
+	if err := writeTestFile(service.storagePath); err != nil {
+		t.Fatal(err)
+	}
+
+	// a test file of a concurrent health check that wasn't removed yet
+	if err := os.WriteFile(filepath.Join(service.storagePath, healthTestFilePrefix+"123"), []byte{0}, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	logFileInfos, unknown, err := service.ListLogFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(logFileInfos) != 0 || len(unknown) != 0 {
+		t.Errorf("expected no files, got %v and unknown files %v", logFileInfos, unknown)
+	}
+}
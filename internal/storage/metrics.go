@@ -0,0 +1,80 @@
+package storage
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"simple-log-store/internal/metrics"
+)
+
+type storageMetrics struct {
+	stagedFiles      prometheus.Counter
+	stagedBytes      prometheus.Counter
+	committedFiles   prometheus.Counter
+	committedBytes   prometheus.Counter
+	commitFailures   prometheus.Counter
+	quarantinedFiles prometheus.Counter
+	cleanupRuns      prometheus.Counter
+	removedFiles     prometheus.Counter
+	storageBytes     prometheus.Gauge
+}
+
+func newStorageMetrics(registerer prometheus.Registerer) *storageMetrics {
+	factory := promauto.With(registerer)
+
+	return &storageMetrics{
+		stagedFiles: factory.NewCounter(prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: "storage",
+			Name:      "staged_files_total",
+			Help:      "Number of log files written to the staging directory.",
+		}),
+		stagedBytes: factory.NewCounter(prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: "storage",
+			Name:      "staged_bytes_total",
+			Help:      "Number of bytes written to the staging directory.",
+		}),
+		committedFiles: factory.NewCounter(prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: "storage",
+			Name:      "committed_files_total",
+			Help:      "Number of log files moved from the staging directory to the storage directory.",
+		}),
+		committedBytes: factory.NewCounter(prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: "storage",
+			Name:      "committed_bytes_total",
+			Help:      "Number of bytes moved from the staging directory to the storage directory.",
+		}),
+		commitFailures: factory.NewCounter(prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: "storage",
+			Name:      "commit_failures_total",
+			Help:      "Number of log files that couldn't be moved to the storage directory.",
+		}),
+		quarantinedFiles: factory.NewCounter(prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: "storage",
+			Name:      "quarantined_files_total",
+			Help:      "Number of log files rejected by an ingestion hook and moved to the quarantine directory.",
+		}),
+		cleanupRuns: factory.NewCounter(prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: "storage",
+			Name:      "cleanup_runs_total",
+			Help:      "Number of times old log files have been removed.",
+		}),
+		removedFiles: factory.NewCounter(prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: "storage",
+			Name:      "removed_files_total",
+			Help:      "Number of log files removed from the storage directory.",
+		}),
+		storageBytes: factory.NewGauge(prometheus.GaugeOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: "storage",
+			Name:      "bytes",
+			Help:      "Current size of all log files in the storage directory.",
+		}),
+	}
+}
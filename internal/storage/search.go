@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"bufio"
+	"fmt"
+	"regexp"
+	"simple-log-store/internal/charset"
+	"simple-log-store/internal/logs"
+)
+
+type SearchMatch struct {
+	LogFileId logs.LogFileId
+	Line      uint64
+	Text      string
+}
+
+// SearchLogFile returns up to limit lines of the log file that match the pattern, after
+// decoding them from the encoding to UTF-8. The second return value is true if there are
+// more matches than the limit.
+func (s *Service) SearchLogFile(logFileId logs.LogFileId, encoding charset.Encoding, pattern *regexp.Regexp, limit int) ([]SearchMatch, bool, error) {
+	file, err := s.OpenDecodedLogFile(logFileId, encoding)
+	if err != nil {
+		return nil, false, err
+	}
+
+	defer func() {
+		_ = file.Close()
+	}()
+
+	var res []SearchMatch
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), file.MaxLineLength)
+
+	var lineNumber uint64
+	for scanner.Scan() {
+		lineNumber += 1
+
+		line := scanner.Bytes()
+		if !pattern.Match(line) {
+			continue
+		}
+
+		if len(res) >= limit {
+			return res, true, nil
+		}
+
+		res = append(res, SearchMatch{
+			LogFileId: logFileId,
+			Line:      lineNumber,
+			Text:      string(line),
+		})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return res, false, fmt.Errorf("failed to search log file `%s`: %w", logFileId.String(), err)
+	}
+
+	return res, false, nil
+}
@@ -1,32 +1,79 @@
 package storage
 
 import (
+	"github.com/prometheus/client_golang/prometheus"
 	"io/fs"
 	"log/slog"
+	"path/filepath"
 	"simple-log-store/internal/config"
+	"simple-log-store/internal/ingest"
+	"simple-log-store/internal/logs"
+	"simple-log-store/internal/redact"
+	"sync"
 )
 
 type Service struct {
-	logger *slog.Logger
+	logger  *slog.Logger
+	metrics *storageMetrics
 
 	stagingPath  string
 	storagePath  string
 	moveFileFunc moveFileFunc
 
+	// nil if unredacted log files are allowed
+	redactor *redact.Redactor
+
+	// nil if no ingestion hooks are enabled
+	ingestPipeline *ingest.Pipeline
+	quarantinePath string
+
 	directoryPermissions fs.FileMode
 	filePermissions      fs.FileMode
+
+	pendingCommits    sync.WaitGroup
+	pendingMutex      sync.Mutex
+	pendingLogFileIds map[logs.LogFileId]struct{}
+	// set once the shutdown waits for the pending commits
+	closed bool
 }
 
 const defaultDirectoryPermissions = fs.FileMode(0770)
 const defaultFilePermissions = fs.FileMode(0660)
 
-func CreateService(appConfig *config.AppConfig, logger *slog.Logger) (*Service, error) {
+// name of the default quarantine directory in the staging directory
+const quarantineDirectoryName = "quarantine"
+
+func CreateService(appConfig *config.AppConfig, logger *slog.Logger, registerer prometheus.Registerer) (*Service, error) {
 	service := &Service{
 		logger:               logger.With(slog.String("service", "storage")),
+		metrics:              newStorageMetrics(registerer),
 		stagingPath:          appConfig.StagingPath,
 		storagePath:          appConfig.StoragePath,
 		directoryPermissions: fixPermissions(appConfig.DirectoryPermissions, defaultDirectoryPermissions),
 		filePermissions:      fixPermissions(appConfig.FilePermissions, defaultFilePermissions),
+		pendingLogFileIds:    make(map[logs.LogFileId]struct{}),
+	}
+
+	if !appConfig.AllowUnredactedLogs {
+		redactor, err := createRedactor(appConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		service.redactor = redactor
+	}
+
+	ingestPipeline, err := ingest.CreatePipeline(appConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	service.ingestPipeline = ingestPipeline
+	service.quarantinePath = appConfig.QuarantinePath
+	if service.quarantinePath == "" {
+		// inside of the staging directory, which is the only directory next to the storage
+		// directory that is known to belong to the application
+		service.quarantinePath = filepath.Join(service.stagingPath, quarantineDirectoryName)
 	}
 
 	if service.stagingPath == service.storagePath {
@@ -46,6 +93,20 @@ func CreateService(appConfig *config.AppConfig, logger *slog.Logger) (*Service,
 	return service, nil
 }
 
+func createRedactor(appConfig *config.AppConfig) (*redact.Redactor, error) {
+	detectors, err := redact.GetBuiltinDetectors(appConfig.RedactionDetectors)
+	if err != nil {
+		return nil, err
+	}
+
+	customDetectors, err := redact.CompileDetectors(appConfig.RedactionPatterns)
+	if err != nil {
+		return nil, err
+	}
+
+	return redact.New(append(detectors, customDetectors...)), nil
+}
+
 func fixPermissions(rawPermissions uint32, defaultPermissions fs.FileMode) fs.FileMode {
 	permissions := defaultPermissions
 	if rawPermissions != 0 {
@@ -64,5 +125,17 @@ func (s *Service) init() error {
 		return err
 	}
 
+	if s.ingestPipeline != nil {
+		if err := s.createDirectory(s.quarantinePath); err != nil {
+			return err
+		}
+	}
+
+	storageUsage, err := s.getDirectoryUsage(s.storagePath)
+	if err != nil {
+		return err
+	}
+
+	s.metrics.storageBytes.Set(float64(storageUsage.Bytes))
 	return nil
 }
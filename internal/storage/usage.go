@@ -0,0 +1,9 @@
+package storage
+
+func (s *Service) GetStagingUsage() (DirectoryUsage, error) {
+	return s.getDirectoryUsage(s.stagingPath)
+}
+
+func (s *Service) GetStorageUsage() (DirectoryUsage, error) {
+	return s.getDirectoryUsage(s.storagePath)
+}
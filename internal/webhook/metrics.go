@@ -0,0 +1,36 @@
+package webhook
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"simple-log-store/internal/metrics"
+)
+
+const (
+	attemptResultSuccess = "success"
+	attemptResultFailure = "failure"
+)
+
+type webhookMetrics struct {
+	attempts   *prometheus.CounterVec
+	deliveries *prometheus.CounterVec
+}
+
+func newWebhookMetrics(registerer prometheus.Registerer) *webhookMetrics {
+	factory := promauto.With(registerer)
+
+	return &webhookMetrics{
+		attempts: factory.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: "webhook",
+			Name:      "attempts_total",
+			Help:      "Number of requests sent to webhooks by result.",
+		}, []string{"result"}),
+		deliveries: factory.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: "webhook",
+			Name:      "deliveries_total",
+			Help:      "Number of finished webhook deliveries by status.",
+		}, []string{"status"}),
+	}
+}
@@ -0,0 +1,265 @@
+// Package webhook notifies other services about new log bundles with signed HTTP requests.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/oklog/ulid/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"simple-log-store/internal/config"
+	"simple-log-store/internal/redis"
+	"simple-log-store/internal/utils"
+	"simple-log-store/pkg/types"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// upper limit of the delay between two attempts of a delivery
+const maxBackoff = 10 * time.Minute
+
+// only the beginning of the response is read, so the connection can be reused
+const maxResponseSize = 64 * 1024
+
+// upper limit of the time to record a delivery, so the shutdown isn't blocked by redis
+const saveDeliveryTimeout = 5 * time.Second
+
+type Service struct {
+	logger *slog.Logger
+
+	client         *http.Client
+	urls           []string
+	secret         []byte
+	maxAttempts    int
+	initialBackoff time.Duration
+	timeout        time.Duration
+
+	redisService *redis.Service
+	metrics      *webhookMetrics
+
+	// ctx is canceled to abandon the pending deliveries during the shutdown
+	ctx    context.Context
+	cancel context.CancelFunc
+	// closed is set once the shutdown waits for the pending deliveries, no new deliveries
+	// are started afterward
+	pendingMutex sync.Mutex
+	closed       bool
+	pending      sync.WaitGroup
+}
+
+func CreateService(appConfig *config.AppConfig, logger *slog.Logger, redisService *redis.Service, registerer prometheus.Registerer) (*Service, error) {
+	if len(appConfig.WebhookUrls) != 0 && appConfig.WebhookSecret == "" {
+		return nil, fmt.Errorf("a webhook secret is required to sign the requests to webhooks")
+	}
+
+	for _, webhookUrl := range appConfig.WebhookUrls {
+		parsed, err := url.Parse(webhookUrl)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return nil, fmt.Errorf("invalid webhook URL `%s`, expected an absolute HTTP or HTTPS URL", webhookUrl)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	service := &Service{
+		logger: logger.With(slog.String("service", "webhook")),
+
+		client:         &http.Client{},
+		urls:           appConfig.WebhookUrls,
+		secret:         []byte(appConfig.WebhookSecret),
+		maxAttempts:    max(1, int(appConfig.WebhookMaxAttempts)),
+		initialBackoff: appConfig.WebhookInitialBackoff,
+		timeout:        appConfig.WebhookTimeout,
+
+		redisService: redisService,
+		metrics:      newWebhookMetrics(registerer),
+
+		ctx:    ctx,
+		cancel: cancel,
+	}
+
+	return service, nil
+}
+
+// Enabled returns true if at least one webhook is configured.
+func (s *Service) Enabled() bool {
+	return len(s.urls) != 0
+}
+
+// Send delivers the payload to every webhook in a new goroutine, so it never blocks. Failed
+// requests are retried with an exponential backoff and every delivery is recorded in redis.
+// Deliveries are only kept in memory, so delivery is not guaranteed: deliveries that are
+// still pending when the shutdown timeout expires are recorded as failed, and payloads sent
+// after the shutdown started are dropped.
+func (s *Service) Send(payload types.WebhookPayload) {
+	if !s.Enabled() {
+		return
+	}
+
+	s.pendingMutex.Lock()
+	defer s.pendingMutex.Unlock()
+
+	if s.closed {
+		s.logger.Error("dropping webhook payload sent during shutdown", slog.String("logBundleId", payload.BundleId.String()), slog.String("event", payload.Event))
+		s.metrics.deliveries.WithLabelValues(types.DeliveryStatusFailed).Add(float64(len(s.urls)))
+		return
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		s.logger.Error("failed to marshal webhook payload", slog.String("logBundleId", payload.BundleId.String()), utils.ErrAttr(err))
+		return
+	}
+
+	for _, webhookUrl := range s.urls {
+		delivery := redis.WebhookDelivery{
+			DeliveryId:  ulid.Make(),
+			LogBundleId: payload.BundleId,
+			Event:       payload.Event,
+			Url:         webhookUrl,
+			Status:      types.DeliveryStatusPending,
+			UpdatedAt:   time.Now().UTC(),
+		}
+
+		s.pending.Add(1)
+		go s.deliver(delivery, body)
+	}
+}
+
+func (s *Service) deliver(delivery redis.WebhookDelivery, body []byte) {
+	defer s.pending.Done()
+
+	logger := s.logger.With(slog.String("deliveryId", delivery.DeliveryId.String()), slog.String("url", delivery.Url))
+	s.saveDelivery(logger, delivery)
+
+	backoff := s.initialBackoff
+	for {
+		delivery.Attempts += 1
+		statusCode, err := s.post(delivery, body)
+
+		delivery.StatusCode = statusCode
+		delivery.Error = ""
+		delivery.UpdatedAt = time.Now().UTC()
+
+		if err == nil {
+			s.metrics.attempts.WithLabelValues(attemptResultSuccess).Inc()
+			s.metrics.deliveries.WithLabelValues(types.DeliveryStatusSucceeded).Inc()
+			delivery.Status = types.DeliveryStatusSucceeded
+			s.saveDelivery(logger, delivery)
+			return
+		}
+
+		s.metrics.attempts.WithLabelValues(attemptResultFailure).Inc()
+		delivery.Error = err.Error()
+
+		if delivery.Attempts >= s.maxAttempts {
+			logger.Error("giving up delivery to webhook", slog.Int("attempts", delivery.Attempts), utils.ErrAttr(err))
+			s.metrics.deliveries.WithLabelValues(types.DeliveryStatusFailed).Inc()
+			delivery.Status = types.DeliveryStatusFailed
+			s.saveDelivery(logger, delivery)
+			return
+		}
+
+		logger.Warn("failed to deliver to webhook, retrying", slog.Int("attempts", delivery.Attempts), slog.Duration("backoff", backoff), utils.ErrAttr(err))
+		s.saveDelivery(logger, delivery)
+
+		select {
+		case <-s.ctx.Done():
+			logger.Error("abandoning delivery to webhook during shutdown", slog.Int("attempts", delivery.Attempts))
+			s.metrics.deliveries.WithLabelValues(types.DeliveryStatusFailed).Inc()
+			delivery.Status = types.DeliveryStatusFailed
+			delivery.Error = "abandoned during shutdown after: " + delivery.Error
+			s.saveDelivery(logger, delivery)
+			return
+		case <-time.After(backoff):
+		}
+
+		backoff = min(backoff*2, maxBackoff)
+	}
+}
+
+// post sends a single request to the webhook and returns the status code of the response,
+// which is 0 if no response was received.
+func (s *Service) post(delivery redis.WebhookDelivery, body []byte) (int, error) {
+	ctx, cancel := context.WithTimeout(s.ctx, s.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.Url, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "simple-log-store")
+	req.Header.Set(types.WebhookEventHeader, delivery.Event)
+	req.Header.Set(types.WebhookDeliveryHeader, delivery.DeliveryId.String())
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set(types.WebhookTimestampHeader, timestamp)
+	req.Header.Set(types.WebhookSignatureHeader, sign(s.secret, timestamp, body))
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to send request: %w", err)
+	}
+
+	_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, maxResponseSize))
+	_ = res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return res.StatusCode, fmt.Errorf("unexpected status code %d", res.StatusCode)
+	}
+
+	return res.StatusCode, nil
+}
+
+// saveDelivery records the delivery, failures are only logged because they don't affect the delivery.
+func (s *Service) saveDelivery(logger *slog.Logger, delivery redis.WebhookDelivery) {
+	ctx, cancel := context.WithTimeout(context.Background(), saveDeliveryTimeout)
+	defer cancel()
+
+	if err := s.redisService.SaveWebhookDelivery(ctx, delivery); err != nil {
+		logger.Error("failed to save webhook delivery", utils.ErrAttr(err))
+	}
+}
+
+// sign returns the value of the signature header for the body sent at the timestamp.
+func sign(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// WaitForPendingDeliveries stops accepting new deliveries and blocks until all deliveries are
+// done or the context is done. In the latter case, all pending deliveries are abandoned and
+// recorded as failed. Either way, no delivery is running once it returns.
+func (s *Service) WaitForPendingDeliveries(ctx context.Context) error {
+	s.pendingMutex.Lock()
+	s.closed = true
+	s.pendingMutex.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.pending.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		s.cancel()
+		return nil
+	case <-ctx.Done():
+		// requests are canceled as well, so the deliveries return quickly
+		s.cancel()
+		<-done
+		return ctx.Err()
+	}
+}
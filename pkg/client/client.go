@@ -0,0 +1,160 @@
+// Package client implements a client for the HTTP API of the log store.
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/oklog/ulid/v2"
+	"io"
+	"net/http"
+	"net/url"
+	"simple-log-store/pkg/types"
+	"strings"
+)
+
+type Client struct {
+	baseUrl    *url.URL
+	httpClient *http.Client
+	token      string
+}
+
+type Option func(*Client)
+
+// WithHttpClient replaces the default http.Client.
+func WithHttpClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithToken sends the token with every request. Upload keys identify the uploader and admin
+// tokens are required for administration endpoints like ListBundles.
+func WithToken(token string) Option {
+	return func(c *Client) {
+		c.token = token
+	}
+}
+
+// New creates a new client for the server at baseUrl, e.g. `https://logs.example.com`.
+func New(baseUrl string, options ...Option) (*Client, error) {
+	parsed, err := url.Parse(strings.TrimSuffix(baseUrl, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse base URL `%s`: %w", baseUrl, err)
+	}
+
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return nil, fmt.Errorf("base URL `%s` must use http or https", baseUrl)
+	}
+
+	c := &Client{
+		baseUrl:    parsed,
+		httpClient: http.DefaultClient,
+	}
+
+	for _, option := range options {
+		option(c)
+	}
+
+	return c, nil
+}
+
+// ResponseError is returned if the server responds with an unexpected status code.
+type ResponseError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *ResponseError) Error() string {
+	return fmt.Sprintf("server responded with status %d: %s", e.StatusCode, e.Message)
+}
+
+func (c *Client) getUrl(pathFormat string, id ulid.ULID) string {
+	return c.baseUrl.String() + fmt.Sprintf(pathFormat, id.String())
+}
+
+// BundleUrl returns the URL of the JSON representation of the log bundle.
+func (c *Client) BundleUrl(logBundleId ulid.ULID) string {
+	return c.getUrl(types.BundlePath, logBundleId)
+}
+
+// ViewUrl returns the URL of the log bundle in the frontend.
+func (c *Client) ViewUrl(logBundleId ulid.ULID) string {
+	return c.getUrl(types.ViewPath, logBundleId)
+}
+
+// FileUrl returns the URL of the raw log file.
+func (c *Client) FileUrl(logFileId ulid.ULID) string {
+	return c.getUrl(types.FilePath, logFileId)
+}
+
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request to `%s`: %w", req.URL.String(), err)
+	}
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		defer func() {
+			_ = res.Body.Close()
+		}()
+
+		message, _ := io.ReadAll(io.LimitReader(res.Body, 4096))
+		return nil, &ResponseError{
+			StatusCode: res.StatusCode,
+			Message:    strings.TrimSpace(string(message)),
+		}
+	}
+
+	return res, nil
+}
+
+func (c *Client) get(ctx context.Context, requestUrl string, w io.Writer) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, requestUrl, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	res, err := c.do(req)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = res.Body.Close()
+	}()
+
+	if _, err := io.Copy(w, res.Body); err != nil {
+		return fmt.Errorf("failed to read response from `%s`: %w", requestUrl, err)
+	}
+
+	return nil
+}
+
+func (c *Client) getJson(ctx context.Context, requestUrl string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, requestUrl, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/json")
+
+	res, err := c.do(req)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = res.Body.Close()
+	}()
+
+	if err := json.NewDecoder(res.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode response from `%s`: %w", requestUrl, err)
+	}
+
+	return nil
+}
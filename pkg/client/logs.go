@@ -0,0 +1,338 @@
+package client
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/oklog/ulid/v2"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"simple-log-store/pkg/types"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// File is a single file of an upload.
+type File struct {
+	Name   string
+	Reader io.Reader
+}
+
+type UploadOptions struct {
+	// Gzip compresses every file before sending it, the server stores the decompressed file.
+	Gzip bool
+
+	// Title, Description and Tags label the new log bundle and are optional.
+	Title       string
+	Description string
+	Tags        map[string]string
+}
+
+// Upload uploads the files as a new log bundle. The body is streamed while the files are
+// read, so it's sent without a Content-Length.
+func (c *Client) Upload(ctx context.Context, files []File, options UploadOptions) (*types.UploadResponse, error) {
+	bodyReader, bodyWriter := io.Pipe()
+	multipartWriter := multipart.NewWriter(bodyWriter)
+
+	written := make(chan struct{})
+	go func() {
+		defer close(written)
+		_ = bodyWriter.CloseWithError(writeBody(multipartWriter, files, options))
+	}()
+
+	// the writer blocks until the body is read, so it's unblocked if the request fails early,
+	// and the files aren't read anymore once Upload returns
+	defer func() {
+		_ = bodyReader.Close()
+		<-written
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseUrl.String()+types.UploadPath, bodyReader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", multipartWriter.FormDataContentType())
+	req.Header.Set("Accept", "application/json")
+
+	res, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = res.Body.Close()
+	}()
+
+	var uploadResponse types.UploadResponse
+	if err := json.NewDecoder(res.Body).Decode(&uploadResponse); err != nil {
+		return nil, fmt.Errorf("failed to decode upload response: %w", err)
+	}
+
+	return &uploadResponse, nil
+}
+
+// writeBody writes the labels and all files to the multipart body.
+func writeBody(multipartWriter *multipart.Writer, files []File, options UploadOptions) error {
+	if err := writeLabels(multipartWriter, options); err != nil {
+		return fmt.Errorf("failed to write labels: %w", err)
+	}
+
+	for i, file := range files {
+		if err := writePart(multipartWriter, i, file, options); err != nil {
+			return fmt.Errorf("failed to write file `%s`: %w", file.Name, err)
+		}
+	}
+
+	if err := multipartWriter.Close(); err != nil {
+		return fmt.Errorf("failed to finish multipart body: %w", err)
+	}
+
+	return nil
+}
+
+func writeLabels(multipartWriter *multipart.Writer, options UploadOptions) error {
+	if options.Title != "" {
+		if err := multipartWriter.WriteField(types.TitleField, options.Title); err != nil {
+			return err
+		}
+	}
+
+	if options.Description != "" {
+		if err := multipartWriter.WriteField(types.DescriptionField, options.Description); err != nil {
+			return err
+		}
+	}
+
+	for key, value := range options.Tags {
+		if err := multipartWriter.WriteField(types.TagField, key+":"+value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writePart(multipartWriter *multipart.Writer, index int, file File, options UploadOptions) error {
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`, "file"+strconv.Itoa(index), escapeQuotes(file.Name)))
+	header.Set("Content-Type", "application/octet-stream")
+
+	if options.Gzip {
+		header.Set("Content-Encoding", "gzip")
+	}
+
+	partWriter, err := multipartWriter.CreatePart(header)
+	if err != nil {
+		return err
+	}
+
+	if !options.Gzip {
+		_, err = io.Copy(partWriter, file.Reader)
+		return err
+	}
+
+	gzipWriter := gzip.NewWriter(partWriter)
+	if _, err := io.Copy(gzipWriter, file.Reader); err != nil {
+		return err
+	}
+
+	return gzipWriter.Close()
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+func escapeQuotes(s string) string {
+	return quoteEscaper.Replace(s)
+}
+
+// GetBundle returns the IDs of all log files in the log bundle.
+func (c *Client) GetBundle(ctx context.Context, logBundleId ulid.ULID) (types.BundleResponse, error) {
+	var bundleResponse types.BundleResponse
+	if err := c.getJson(ctx, c.BundleUrl(logBundleId), &bundleResponse); err != nil {
+		return nil, err
+	}
+
+	return bundleResponse, nil
+}
+
+// GetBundleDetails returns the IDs of all log files in the log bundle and its labels.
+func (c *Client) GetBundleDetails(ctx context.Context, logBundleId ulid.ULID) (*types.BundleDetailsResponse, error) {
+	query := url.Values{}
+	query.Set(types.BundleDetailsParam, "1")
+
+	var bundleResponse types.BundleDetailsResponse
+	if err := c.getJson(ctx, c.BundleUrl(logBundleId)+"?"+query.Encode(), &bundleResponse); err != nil {
+		return nil, err
+	}
+
+	return &bundleResponse, nil
+}
+
+// DownloadFile writes the contents of the log file to w.
+func (c *Client) DownloadFile(ctx context.Context, logFileId ulid.ULID, w io.Writer) error {
+	return c.get(ctx, c.FileUrl(logFileId), w)
+}
+
+type LineOptions struct {
+	// First and Last are the 1-based line numbers of an inclusive range, they are ignored if
+	// Tail is set.
+	First int
+	Last  int
+	// Tail is the number of lines at the end of the log file.
+	Tail int
+}
+
+// DownloadLines writes the selected lines of the log file to w, decoded to UTF-8.
+func (c *Client) DownloadLines(ctx context.Context, logFileId ulid.ULID, options LineOptions, w io.Writer) error {
+	query := url.Values{}
+	if options.Tail > 0 {
+		query.Set(types.TailParam, strconv.Itoa(options.Tail))
+	} else {
+		query.Set(types.LinesParam, fmt.Sprintf("%d-%d", options.First, options.Last))
+	}
+
+	return c.get(ctx, c.FileUrl(logFileId)+"?"+query.Encode(), w)
+}
+
+// DownloadArchive writes a zip archive of all log files in the log bundle to w.
+func (c *Client) DownloadArchive(ctx context.Context, logBundleId ulid.ULID, w io.Writer) error {
+	return c.get(ctx, c.getUrl(types.ArchivePath, logBundleId), w)
+}
+
+type SearchOptions struct {
+	// Query is a plain substring unless Regex is set.
+	Query           string
+	Regex           bool
+	CaseInsensitive bool
+	// Limit is the maximum number of matches, the server default is used if 0.
+	Limit int
+}
+
+// Search returns all lines in the log bundle that match the query.
+func (c *Client) Search(ctx context.Context, logBundleId ulid.ULID, options SearchOptions) (*types.SearchResponse, error) {
+	query := url.Values{}
+	query.Set(types.SearchQueryParam, options.Query)
+
+	if options.Regex {
+		query.Set(types.SearchRegexParam, "1")
+	}
+
+	if options.CaseInsensitive {
+		query.Set(types.SearchCaseInsensitiveParam, "1")
+	}
+
+	if options.Limit > 0 {
+		query.Set(types.SearchLimitParam, strconv.Itoa(options.Limit))
+	}
+
+	var searchResponse types.SearchResponse
+	if err := c.getJson(ctx, c.getUrl(types.SearchPath, logBundleId)+"?"+query.Encode(), &searchResponse); err != nil {
+		return nil, err
+	}
+
+	return &searchResponse, nil
+}
+
+type ListOptions struct {
+	// Tags must all be present with the same value.
+	Tags     map[string]string
+	Uploader string
+	// From and To limit the time the log bundles were created, zero times don't limit it.
+	From    time.Time
+	To      time.Time
+	MinSize uint64
+	MaxSize uint64
+	// Cursor is the NextCursor of the previous page.
+	Cursor string
+	// Limit is the maximum number of log bundles, the server default is used if 0.
+	Limit int
+}
+
+// ListBundles returns a page of log bundles, newest first. It requires an admin token.
+func (c *Client) ListBundles(ctx context.Context, options ListOptions) (*types.BundleListResponse, error) {
+	query := url.Values{}
+	for key, value := range options.Tags {
+		query.Add(types.ListTagParam, key+":"+value)
+	}
+
+	if options.Uploader != "" {
+		query.Set(types.ListUploaderParam, options.Uploader)
+	}
+
+	if !options.From.IsZero() {
+		query.Set(types.ListFromParam, options.From.Format(time.RFC3339))
+	}
+
+	if !options.To.IsZero() {
+		query.Set(types.ListToParam, options.To.Format(time.RFC3339))
+	}
+
+	if options.MinSize > 0 {
+		query.Set(types.ListMinSizeParam, strconv.FormatUint(options.MinSize, 10))
+	}
+
+	if options.MaxSize > 0 {
+		query.Set(types.ListMaxSizeParam, strconv.FormatUint(options.MaxSize, 10))
+	}
+
+	if options.Cursor != "" {
+		query.Set(types.ListCursorParam, options.Cursor)
+	}
+
+	if options.Limit > 0 {
+		query.Set(types.ListLimitParam, strconv.Itoa(options.Limit))
+	}
+
+	var listResponse types.BundleListResponse
+	if err := c.getJson(ctx, c.baseUrl.String()+types.BundlesPath+"?"+query.Encode(), &listResponse); err != nil {
+		return nil, err
+	}
+
+	return &listResponse, nil
+}
+
+type DeliveryOptions struct {
+	// BundleId only returns the deliveries of the log bundle if it isn't zero.
+	BundleId ulid.ULID
+	// Status is one of types.DeliveryStatusPending, types.DeliveryStatusSucceeded or
+	// types.DeliveryStatusFailed, all deliveries are returned if it's empty.
+	Status string
+	// Cursor is the NextCursor of the previous page.
+	Cursor string
+	// Limit is the maximum number of deliveries, the server default is used if 0.
+	Limit int
+}
+
+// ListDeliveries returns a page of webhook deliveries, newest first. It requires an admin token.
+func (c *Client) ListDeliveries(ctx context.Context, options DeliveryOptions) (*types.DeliveryListResponse, error) {
+	query := url.Values{}
+	var zeroId ulid.ULID
+	if options.BundleId != zeroId {
+		query.Set(types.DeliveryBundleParam, options.BundleId.String())
+	}
+
+	if options.Status != "" {
+		query.Set(types.DeliveryStatusParam, options.Status)
+	}
+
+	if options.Cursor != "" {
+		query.Set(types.DeliveryCursorParam, options.Cursor)
+	}
+
+	if options.Limit > 0 {
+		query.Set(types.DeliveryLimitParam, strconv.Itoa(options.Limit))
+	}
+
+	var listResponse types.DeliveryListResponse
+	if err := c.getJson(ctx, c.baseUrl.String()+types.DeliveriesPath+"?"+query.Encode(), &listResponse); err != nil {
+		return nil, err
+	}
+
+	return &listResponse, nil
+}
@@ -0,0 +1,219 @@
+// Package types contains the request and response types of the HTTP API. They are shared
+// between the server and the client.
+package types
+
+import (
+	"github.com/oklog/ulid/v2"
+	"time"
+)
+
+// Paths of the HTTP API.
+const (
+	UploadPath  = "/logs"
+	FilePath    = "/logs/file/%s"
+	BundlePath  = "/logs/bundle/%s"
+	ArchivePath = "/logs/bundle/%s/archive"
+	SearchPath  = "/logs/bundle/%s/search"
+	ViewPath    = "/view/bundle/%s"
+	BundlesPath = "/logs/bundles"
+	// DeliveriesPath lists the deliveries of webhooks.
+	DeliveriesPath = "/logs/webhooks/deliveries"
+)
+
+// StripAnsiParam is the query parameter of `GET /logs/file/{logFileId}` that removes ANSI
+// escape sequences from the log file when set to `1`.
+const StripAnsiParam = "strip_ansi"
+
+// EncodingParam is the query parameter of `GET /logs/file/{logFileId}` that decodes the log
+// file from its detected encoding when set to `utf-8`. Without it, the original bytes are served.
+const EncodingParam = "encoding"
+
+// Query parameters of `GET /logs/file/{logFileId}` that select lines instead of serving the
+// entire log file. Lines is an inclusive range of 1-based line numbers like `1000-2000`, tail
+// is the number of lines at the end. The lines are always decoded to UTF-8.
+const (
+	LinesParam = "lines"
+	TailParam  = "tail"
+)
+
+// LineRangeHeader is the header of responses with selected lines, like `1000-2000/5234`. The
+// last number is the number of lines of the log file, the range is `*` if no lines were selected.
+const LineRangeHeader = "X-Line-Range"
+
+// Multipart fields of `POST /logs` that label the new log bundle instead of being uploaded as
+// log files. Fields with a file name are always uploaded as log files. Tags are formatted as
+// `key:value`, the tag field can be repeated and the tags field contains comma separated tags.
+const (
+	TitleField       = "title"
+	DescriptionField = "description"
+	TagField         = "tag"
+	TagsField        = "tags"
+)
+
+// Headers of `POST /logs` that label the new log bundle, as an alternative to the multipart
+// fields. The tags header contains comma separated tags and can be repeated.
+const (
+	TitleHeader       = "X-Bundle-Title"
+	DescriptionHeader = "X-Bundle-Description"
+	TagsHeader        = "X-Bundle-Tags"
+)
+
+// UploadResponse is returned by `POST /logs` if the client accepts `application/json`.
+// Otherwise, the response only contains the bundle ID as plain text.
+type UploadResponse struct {
+	BundleId ulid.ULID   `json:"bundleId"`
+	FileIds  []ulid.ULID `json:"fileIds"`
+}
+
+// BundleResponse is returned by `GET /logs/bundle/{logBundleId}` and contains the IDs
+// of all log files in the bundle.
+type BundleResponse []ulid.ULID
+
+// BundleDetailsParam is the query parameter of `GET /logs/bundle/{logBundleId}` that returns
+// BundleDetailsResponse instead of BundleResponse when set to `1`.
+const BundleDetailsParam = "details"
+
+// BundleDetailsResponse contains the IDs of all log files in the bundle and its labels.
+type BundleDetailsResponse struct {
+	FileIds     []ulid.ULID       `json:"fileIds"`
+	Title       string            `json:"title,omitempty"`
+	Description string            `json:"description,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"`
+}
+
+// Query parameters of `GET /logs/bundle/{logBundleId}/search`.
+const (
+	SearchQueryParam           = "q"
+	SearchRegexParam           = "regex"
+	SearchCaseInsensitiveParam = "i"
+	SearchLimitParam           = "limit"
+)
+
+// SearchResponse is returned by `GET /logs/bundle/{logBundleId}/search`.
+type SearchResponse struct {
+	Matches []SearchMatch `json:"matches"`
+	// Truncated is true if the search stopped after reaching the limit.
+	Truncated bool `json:"truncated"`
+}
+
+type SearchMatch struct {
+	FileId ulid.ULID `json:"fileId"`
+	// Line is the 1-based line number of the match.
+	Line uint64 `json:"line"`
+	Text string `json:"text"`
+}
+
+// Query parameters of `GET /logs/bundles`, which requires an admin token. Tags are formatted
+// as `key:value` and the tag parameter can be repeated. Times are formatted as RFC 3339.
+const (
+	ListTagParam      = "tag"
+	ListUploaderParam = "uploader"
+	ListFromParam     = "from"
+	ListToParam       = "to"
+	ListMinSizeParam  = "min_size"
+	ListMaxSizeParam  = "max_size"
+	ListCursorParam   = "cursor"
+	ListLimitParam    = "limit"
+)
+
+// BundleListResponse is returned by `GET /logs/bundles`.
+type BundleListResponse struct {
+	// Bundles are ordered from newest to oldest.
+	Bundles []BundleSummary `json:"bundles"`
+	// NextCursor is the cursor parameter of the next page, it's empty on the last page.
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+type BundleSummary struct {
+	BundleId  ulid.ULID `json:"bundleId"`
+	CreatedAt time.Time `json:"createdAt"`
+	BundleDetailsResponse
+	// Uploader is the name of the upload key, empty for anonymous uploads.
+	Uploader string `json:"uploader,omitempty"`
+	// Size is the number of bytes uploaded, 0 if unknown.
+	Size uint64 `json:"size"`
+}
+
+// Headers of the requests sent to webhooks. The timestamp is the time of the attempt in Unix
+// seconds. The signature is the hex encoded HMAC-SHA256 of the timestamp, a dot and the request
+// body, keyed with the webhook secret and prefixed with `sha256=`. Receivers should reject
+// requests with a timestamp that differs by more than WebhookTimestampTolerance from their
+// clock, so captured requests can't be replayed.
+const (
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookDeliveryHeader  = "X-Webhook-Delivery"
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+	WebhookSignatureHeader = "X-Webhook-Signature-256"
+)
+
+// WebhookTimestampTolerance is the recommended maximum age of a webhook request. Every attempt
+// of a delivery is signed with a new timestamp.
+const WebhookTimestampTolerance = 5 * time.Minute
+
+// WebhookEventBundleCommitted is sent after the log files of a new log bundle were committed.
+const WebhookEventBundleCommitted = "bundle.committed"
+
+// WebhookPayload is the body of the requests sent to webhooks.
+type WebhookPayload struct {
+	Event     string    `json:"event"`
+	BundleId  ulid.ULID `json:"bundleId"`
+	CreatedAt time.Time `json:"createdAt"`
+	// ViewUrl is only set if the public URL of the server is configured.
+	ViewUrl     string            `json:"viewUrl,omitempty"`
+	Title       string            `json:"title,omitempty"`
+	Description string            `json:"description,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"`
+	Uploader    string            `json:"uploader,omitempty"`
+	Files       []WebhookFile     `json:"files"`
+	// FailedFileIds are the log files of the bundle that couldn't be committed, including quarantined log files.
+	FailedFileIds []ulid.ULID `json:"failedFileIds,omitempty"`
+}
+
+// WebhookFile is a committed log file of a WebhookPayload. The line count, format and level
+// counts are missing if the log file couldn't be indexed.
+type WebhookFile struct {
+	FileId      ulid.ULID         `json:"fileId"`
+	Name        string            `json:"name,omitempty"`
+	Size        uint64            `json:"size"`
+	LineCount   uint64            `json:"lineCount,omitempty"`
+	Format      string            `json:"format,omitempty"`
+	LevelCounts map[string]uint64 `json:"levelCounts,omitempty"`
+	Redactions  map[string]uint64 `json:"redactions,omitempty"`
+}
+
+// Query parameters of `GET /logs/webhooks/deliveries`, which requires an admin token.
+const (
+	DeliveryBundleParam = "bundle"
+	DeliveryStatusParam = "status"
+	DeliveryCursorParam = "cursor"
+	DeliveryLimitParam  = "limit"
+)
+
+// Statuses of webhook deliveries.
+const (
+	DeliveryStatusPending   = "pending"
+	DeliveryStatusSucceeded = "succeeded"
+	DeliveryStatusFailed    = "failed"
+)
+
+// DeliveryListResponse is returned by `GET /logs/webhooks/deliveries`.
+type DeliveryListResponse struct {
+	// Deliveries are ordered from newest to oldest.
+	Deliveries []Delivery `json:"deliveries"`
+	// NextCursor is the cursor parameter of the next page, it's empty on the last page.
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+// Delivery is the request of a webhook for a single event.
+type Delivery struct {
+	DeliveryId ulid.ULID `json:"deliveryId"`
+	BundleId   ulid.ULID `json:"bundleId"`
+	Event      string    `json:"event"`
+	Url        string    `json:"url"`
+	Status     string    `json:"status"`
+	Attempts   int       `json:"attempts"`
+	// StatusCode and Error describe the last attempt.
+	StatusCode int       `json:"statusCode,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}